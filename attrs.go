@@ -0,0 +1,105 @@
+package sphinx
+
+// SetAttrAliases registers user-friendly names for attribute columns (e.g.
+// "created" -> "date_added"), so callers can read Match values through
+// Get/Int/Float/String/MVA without coupling to the exact index schema.
+// The alias map is snapshotted onto each Result as RunQueries decodes it.
+func (sc *Client) SetAttrAliases(aliases map[string]string) *Client {
+	sc.attrAliases = aliases
+	return sc
+}
+
+// finalizeResult builds r's name-addressable attribute index, snapshots the
+// client's alias map onto it, and backlinks each Match to r. Called once
+// per freshly decoded Result, right after RunQueries reads it off the wire.
+func (sc *Client) finalizeResult(r *Result) {
+	r.attrIndex = make(map[string]int, len(r.AttrNames))
+	for i, name := range r.AttrNames {
+		r.attrIndex[name] = i
+	}
+	r.aliases = sc.attrAliases
+
+	for i := range r.Matches {
+		r.Matches[i].result = r
+	}
+}
+
+// resolveAttr resolves name to an index into AttrTypes/AttrValues, checking
+// r.aliases first and falling back to the real AttrNames entry.
+func (r *Result) resolveAttr(name string) (int, bool) {
+	if real, ok := r.aliases[name]; ok {
+		name = real
+	}
+	i, ok := r.attrIndex[name]
+	return i, ok
+}
+
+// Get returns the raw value and SPH_ATTR_* type of the attribute named
+// name (aliases are resolved first), or ok=false if name isn't a known
+// attribute on this match's Result.
+func (m Match) Get(name string) (value interface{}, attrType int, ok bool) {
+	i, found := m.result.resolveAttr(name)
+	if !found {
+		return nil, 0, false
+	}
+	return m.AttrValues[i], m.result.AttrTypes[i], true
+}
+
+// Int returns the named attribute as an int64. ok is false if the
+// attribute is unknown or not an integer-family SPH_ATTR_* type.
+func (m Match) Int(name string) (int64, bool) {
+	v, attrType, ok := m.Get(name)
+	if !ok {
+		return 0, false
+	}
+	switch attrType {
+	case SPH_ATTR_BIGINT:
+		return int64(v.(uint64)), true
+	case SPH_ATTR_FLOAT, SPH_ATTR_STRING, SPH_ATTR_MULTI, SPH_ATTR_MULTI64:
+		return 0, false
+	default:
+		return int64(v.(uint32)), true
+	}
+}
+
+// Float returns the named attribute as a float32. ok is false if the
+// attribute is unknown or not SPH_ATTR_FLOAT.
+func (m Match) Float(name string) (float32, bool) {
+	v, attrType, ok := m.Get(name)
+	if !ok || attrType != SPH_ATTR_FLOAT {
+		return 0, false
+	}
+	return v.(float32), true
+}
+
+// String returns the named attribute as a string. ok is false if the
+// attribute is unknown or not SPH_ATTR_STRING.
+func (m Match) String(name string) (string, bool) {
+	v, attrType, ok := m.Get(name)
+	if !ok || attrType != SPH_ATTR_STRING {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// MVA returns the named attribute as a []uint64. ok is false if the
+// attribute is unknown or not a multi-value SPH_ATTR_* type.
+func (m Match) MVA(name string) ([]uint64, bool) {
+	v, attrType, ok := m.Get(name)
+	if !ok {
+		return nil, false
+	}
+	switch attrType {
+	case SPH_ATTR_MULTI:
+		vals := v.([]uint32)
+		out := make([]uint64, len(vals))
+		for i, x := range vals {
+			out[i] = uint64(x)
+		}
+		return out, true
+	case SPH_ATTR_MULTI64:
+		return v.([]uint64), true
+	default:
+		return nil, false
+	}
+}
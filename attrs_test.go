@@ -0,0 +1,65 @@
+package sphinx
+
+import "testing"
+
+func newTestResult(names []string, types []int, values []interface{}) Result {
+	r := Result{AttrNames: names, AttrTypes: types}
+	r.Matches = []Match{{DocId: 1, AttrValues: values}}
+
+	sc := &Client{attrAliases: map[string]string{"created": "date_added"}}
+	sc.finalizeResult(&r)
+	return r
+}
+
+func TestMatchInt(t *testing.T) {
+	r := newTestResult([]string{"group_id"}, []int{SPH_ATTR_INTEGER}, []interface{}{uint32(42)})
+
+	v, ok := r.Matches[0].Int("group_id")
+	if !ok || v != 42 {
+		t.Fatalf("Int(group_id) > got %v, %v; want 42, true", v, ok)
+	}
+
+	if _, ok := r.Matches[0].Int("missing"); ok {
+		t.Fatalf("Int(missing) > got ok=true, want false")
+	}
+}
+
+func TestMatchFloat(t *testing.T) {
+	r := newTestResult([]string{"score"}, []int{SPH_ATTR_FLOAT}, []interface{}{float32(1.5)})
+
+	v, ok := r.Matches[0].Float("score")
+	if !ok || v != 1.5 {
+		t.Fatalf("Float(score) > got %v, %v; want 1.5, true", v, ok)
+	}
+
+	if _, ok := r.Matches[0].Int("score"); ok {
+		t.Fatalf("Int(score) > got ok=true for a float attr, want false")
+	}
+}
+
+func TestMatchString(t *testing.T) {
+	r := newTestResult([]string{"title"}, []int{SPH_ATTR_STRING}, []interface{}{"hello"})
+
+	v, ok := r.Matches[0].String("title")
+	if !ok || v != "hello" {
+		t.Fatalf("String(title) > got %v, %v; want hello, true", v, ok)
+	}
+}
+
+func TestMatchMVA(t *testing.T) {
+	r := newTestResult([]string{"tags"}, []int{SPH_ATTR_MULTI}, []interface{}{[]uint32{1, 2, 3}})
+
+	v, ok := r.Matches[0].MVA("tags")
+	if !ok || len(v) != 3 || v[0] != 1 || v[2] != 3 {
+		t.Fatalf("MVA(tags) > got %v, %v; want [1 2 3], true", v, ok)
+	}
+}
+
+func TestMatchAlias(t *testing.T) {
+	r := newTestResult([]string{"date_added"}, []int{SPH_ATTR_INTEGER}, []interface{}{uint32(1000)})
+
+	v, ok := r.Matches[0].Int("created")
+	if !ok || v != 1000 {
+		t.Fatalf("Int(created) > got %v, %v; want 1000, true", v, ok)
+	}
+}
@@ -0,0 +1,303 @@
+package sphinx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRowsPerStatement  = 1000    // default Batch.maxRows
+	DefaultMaxBytesPerStatement = 1 << 20 // default Batch.maxBytes, 1MiB
+)
+
+// Batch buffers struct values for a single RT index and flushes them as
+// chunked, transactional multi-row INSERT/REPLACE statements, instead of
+// issuing one statement per row like Insert/Replace do.
+type Batch struct {
+	sc      *Client
+	index   string
+	columns []string
+	replace bool
+
+	maxRows  int
+	maxBytes int
+
+	mu   sync.Mutex
+	rows [][]interface{}
+	size int
+
+	autoFlushStop chan struct{}
+	autoFlushErr  error
+
+	committed int
+}
+
+// Begin returns a Batch that buffers rows for index until Flush/Commit (or
+// AutoFlush) writes them out. Call Columns before Add to declare which
+// struct fields map to which SQL columns.
+func (sc *Client) Begin(index string) *Batch {
+	return &Batch{
+		sc:       sc,
+		index:    index,
+		replace:  true,
+		maxRows:  DefaultMaxRowsPerStatement,
+		maxBytes: DefaultMaxBytesPerStatement,
+	}
+}
+
+// Columns declares the struct field names (resolved the same way as
+// GetColArgs/Update) each Add'd row maps to, in column order.
+func (b *Batch) Columns(columns ...string) *Batch {
+	b.columns = columns
+	return b
+}
+
+// AsInsert switches the batch from the default REPLACE to INSERT.
+func (b *Batch) AsInsert() *Batch {
+	b.replace = false
+	return b
+}
+
+// MaxRowsPerStatement caps how many rows are packed into a single INSERT/
+// REPLACE statement.
+func (b *Batch) MaxRowsPerStatement(n int) *Batch {
+	if n > 0 {
+		b.maxRows = n
+	}
+	return b
+}
+
+// MaxBytesPerStatement caps the approximate byte size of a single
+// statement's VALUES list.
+func (b *Batch) MaxBytesPerStatement(n int) *Batch {
+	if n > 0 {
+		b.maxBytes = n
+	}
+	return b
+}
+
+// AutoFlush starts a background goroutine that calls Flush every interval,
+// for streaming ingestion where the caller doesn't want to track buffer
+// size itself. Commit (or Rollback) stops it.
+func (b *Batch) AutoFlush(interval time.Duration) *Batch {
+	if b.autoFlushStop != nil || interval <= 0 {
+		return b
+	}
+
+	b.autoFlushStop = make(chan struct{})
+	stop := b.autoFlushStop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := b.Flush(); err != nil {
+					b.mu.Lock()
+					b.autoFlushErr = err
+					b.mu.Unlock()
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// Add buffers obj's column values (via GetColArgs), flushing automatically
+// once the buffer reaches MaxRowsPerStatement or MaxBytesPerStatement.
+func (b *Batch) Add(obj interface{}) error {
+	if len(b.columns) == 0 {
+		return fmt.Errorf("Batch.Add > Columns must be set before Add")
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(obj))
+	args, err := GetColArgs(val, b.columns)
+	if err != nil {
+		return fmt.Errorf("Batch.Add > %v", err)
+	}
+
+	b.mu.Lock()
+	b.rows = append(b.rows, args)
+	b.size += rowByteSize(args)
+	full := len(b.rows) >= b.maxRows || b.size >= b.maxBytes
+	b.mu.Unlock()
+
+	if full {
+		_, err := b.Flush()
+		return err
+	}
+	return nil
+}
+
+// Flush sends any buffered rows as one or more chunked, transactional
+// INSERT/REPLACE statements and returns how many rows were written.
+func (b *Batch) Flush() (int, error) {
+	return b.FlushContext(context.Background())
+}
+
+// FlushContext is the context-aware sibling of Flush. BEGIN, the chunked
+// INSERT/REPLACE statements and the closing COMMIT/ROLLBACK are all issued
+// over a single pinned *sql.Conn (instead of sc.DB, a pool where each
+// ExecContext call may land on a different connection) so the flush is an
+// actual RT-index transaction.
+func (b *Batch) FlushContext(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	rows := b.rows
+	b.rows = nil
+	b.size = 0
+	b.mu.Unlock()
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	verb := "INSERT"
+	if b.replace {
+		verb = "REPLACE"
+	}
+
+	if b.sc.DB == nil {
+		if err := b.sc.GetDb(); err != nil {
+			return 0, fmt.Errorf("Batch.Flush > %v", err)
+		}
+	}
+
+	conn, err := b.sc.DB.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Batch.Flush > Conn > %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return 0, fmt.Errorf("Batch.Flush > BEGIN > %v", err)
+	}
+
+	written := 0
+	for _, chunk := range chunkRows(rows, b.maxRows, b.maxBytes) {
+		sqlStr, args := buildMultiRowStmt(verb, b.index, b.columns, chunk)
+		if _, err := execOnConn(ctx, conn, sqlStr, args); err != nil {
+			conn.ExecContext(ctx, "ROLLBACK")
+			return written, fmt.Errorf("Batch.Flush > %v", err)
+		}
+		written += len(chunk)
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return written, fmt.Errorf("Batch.Flush > COMMIT > %v", err)
+	}
+
+	b.mu.Lock()
+	b.committed += written
+	b.mu.Unlock()
+	return written, nil
+}
+
+// execOnConn runs sqlStr with positional "?" placeholders bound to args
+// through a prepared statement on conn, mirroring Client.execPreparedContext
+// but pinned to a single *sql.Conn instead of the pool.
+func execOnConn(ctx context.Context, conn *sql.Conn, sqlStr string, args []interface{}) (sql.Result, error) {
+	stmt, err := conn.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, fmt.Errorf("execOnConn > %v", err)
+	}
+	defer stmt.Close()
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+// Commit stops any AutoFlush goroutine, flushes remaining buffered rows,
+// and returns the total number of rows written by this Batch across every
+// Flush call.
+func (b *Batch) Commit() (int, error) {
+	if b.autoFlushStop != nil {
+		close(b.autoFlushStop)
+		b.autoFlushStop = nil
+	}
+
+	_, flushErr := b.Flush()
+
+	b.mu.Lock()
+	committed := b.committed
+	err := b.autoFlushErr
+	b.mu.Unlock()
+
+	if flushErr != nil {
+		return committed, flushErr
+	}
+	return committed, err
+}
+
+// Rollback stops any AutoFlush goroutine and discards buffered, not-yet-
+// flushed rows without writing them.
+func (b *Batch) Rollback() {
+	if b.autoFlushStop != nil {
+		close(b.autoFlushStop)
+		b.autoFlushStop = nil
+	}
+
+	b.mu.Lock()
+	b.rows = nil
+	b.size = 0
+	b.mu.Unlock()
+}
+
+func rowByteSize(args []interface{}) int {
+	n := 0
+	for _, a := range args {
+		switch v := a.(type) {
+		case string:
+			n += len(v)
+		case []byte:
+			n += len(v)
+		default:
+			n += 8
+		}
+	}
+	return n
+}
+
+// chunkRows splits rows into groups that respect both maxRows and
+// maxBytes, preserving row order.
+func chunkRows(rows [][]interface{}, maxRows, maxBytes int) [][][]interface{} {
+	var chunks [][][]interface{}
+	var cur [][]interface{}
+	curBytes := 0
+
+	for _, row := range rows {
+		rowBytes := rowByteSize(row)
+		if len(cur) > 0 && (len(cur) >= maxRows || curBytes+rowBytes > maxBytes) {
+			chunks = append(chunks, cur)
+			cur = nil
+			curBytes = 0
+		}
+		cur = append(cur, row)
+		curBytes += rowBytes
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// buildMultiRowStmt builds "<verb> INTO index (cols) VALUES (?,?),(?,?),..."
+// for chunk, along with its flattened bind arguments.
+func buildMultiRowStmt(verb, index string, columns []string, chunk [][]interface{}) (string, []interface{}) {
+	rowPlaceholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+
+	valuesList := make([]string, len(chunk))
+	args := make([]interface{}, 0, len(chunk)*len(columns))
+	for i, row := range chunk {
+		valuesList[i] = rowPlaceholder
+		args = append(args, row...)
+	}
+
+	sqlStr := fmt.Sprintf("%s INTO %s (%s) VALUES %s", verb, index, strings.Join(columns, ","), strings.Join(valuesList, ","))
+	return sqlStr, args
+}
@@ -0,0 +1,125 @@
+package sphinx
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func be32(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+func TestByteParserReadsWellFormedFrame(t *testing.T) {
+	var stream []byte
+	stream = append(stream, be32(7)...)
+	stream = append(stream, []byte("example")...)
+	stream = append(stream, be32(42)...)
+
+	bp := byteParser{stream: stream}
+	if s := bp.String(); s != "example" {
+		t.Fatalf("String() > got %q, want \"example\"", s)
+	}
+	if n := bp.Int32(); n != 42 {
+		t.Fatalf("Int32() > got %d, want 42", n)
+	}
+	if bp.Err() != nil {
+		t.Fatalf("Err() > got %v, want nil", bp.Err())
+	}
+}
+
+func TestByteParserRejectsTruncatedFixedWidthField(t *testing.T) {
+	bp := byteParser{stream: be32(1)[:2]} // only 2 of 4 bytes present
+	if n := bp.Int32(); n != 0 {
+		t.Fatalf("Int32() on truncated stream > got %d, want 0", n)
+	}
+	if bp.Err() == nil {
+		t.Fatalf("Err() > got nil, want a truncation error")
+	}
+}
+
+func TestByteParserRejectsOversizedStringLength(t *testing.T) {
+	var stream []byte
+	stream = append(stream, be32(1<<20)...) // claims a 1MiB string
+	stream = append(stream, []byte("short")...)
+
+	bp := byteParser{stream: stream, maxStringLen: 16}
+	if s := bp.String(); s != "" {
+		t.Fatalf("String() > got %q, want \"\"", s)
+	}
+	if bp.Err() == nil {
+		t.Fatalf("Err() > got nil, want a MaxStringLen error")
+	}
+}
+
+func TestByteParserRejectsStringLengthPastEndOfBuffer(t *testing.T) {
+	var stream []byte
+	stream = append(stream, be32(100)...) // no bytes actually follow
+	bp := byteParser{stream: stream}
+
+	if s := bp.String(); s != "" {
+		t.Fatalf("String() > got %q, want \"\"", s)
+	}
+	if bp.Err() == nil {
+		t.Fatalf("Err() > got nil, want a truncation error")
+	}
+}
+
+func TestByteParserIsStickyAfterFirstError(t *testing.T) {
+	bp := byteParser{stream: be32(1)[:1]}
+	bp.Int32()
+	firstErr := bp.Err()
+	if firstErr == nil {
+		t.Fatalf("Err() > got nil after a bad read, want an error")
+	}
+
+	if n := bp.Uint32(); n != 0 {
+		t.Fatalf("Uint32() after sticky error > got %d, want 0", n)
+	}
+	if bp.Err() != firstErr {
+		t.Fatalf("Err() > changed after a later call, want it to stay the first error")
+	}
+}
+
+func TestByteParserCountRejectsImplausibleLength(t *testing.T) {
+	var stream []byte
+	stream = append(stream, be32(1<<30)...) // a billion elements claimed
+	stream = append(stream, be32(0)...)     // but only 4 bytes follow
+
+	bp := byteParser{stream: stream}
+	if n := bp.Count(4); n != 0 {
+		t.Fatalf("Count(4) > got %d, want 0", n)
+	}
+	if bp.Err() == nil {
+		t.Fatalf("Err() > got nil, want an implausible-count error")
+	}
+}
+
+func TestByteParserCountAcceptsPlausibleLength(t *testing.T) {
+	var stream []byte
+	stream = append(stream, be32(2)...)
+	stream = append(stream, be32(0)...)
+	stream = append(stream, be32(0)...)
+
+	bp := byteParser{stream: stream}
+	if n := bp.Count(4); n != 2 {
+		t.Fatalf("Count(4) > got %d, want 2", n)
+	}
+	if bp.Err() != nil {
+		t.Fatalf("Err() > got %v, want nil", bp.Err())
+	}
+}
+
+func TestDecodeResultRejectsMalformedFrameWithoutPanicking(t *testing.T) {
+	// Status SEARCHD_OK, then a schema field count claiming far more fields
+	// than the remaining buffer could possibly hold.
+	var stream []byte
+	stream = append(stream, be32(uint32(SEARCHD_OK))...)
+	stream = append(stream, be32(1<<20)...)
+
+	bp := byteParser{stream: stream}
+	if _, err := decodeResult(&bp); err == nil {
+		t.Fatalf("decodeResult > got nil error on a malformed frame, want one")
+	}
+}
@@ -0,0 +1,119 @@
+package sphinx
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the pluggable result cache RunQueries consults before sending a
+// request to searchd. Get reports whether key is present and still fresh;
+// Set stores r under key, to be considered stale after ttl.
+type Cache interface {
+	Get(key string) (*Result, bool)
+	Set(key string, r *Result, ttl time.Duration)
+}
+
+// fingerprint returns a stable cache key for a single AddQuery request,
+// derived from its serialized wire bytes (offset, limit, mode, filters,
+// group-by, weights, select-list, index, query text, comment).
+func fingerprint(req []byte) string {
+	sum := sha256.Sum256(req)
+	return hex.EncodeToString(sum[:])
+}
+
+// SetCache installs c as the result cache consulted by RunQueries, with
+// ttl as the freshness window passed to c.Set. A nil c disables caching.
+func (sc *Client) SetCache(c Cache, ttl time.Duration) *Client {
+	sc.cache = c
+	sc.cacheTTL = ttl
+	return sc
+}
+
+// SetNoCache bypasses the installed cache (both reads and writes) for
+// subsequent RunQueries calls, without uninstalling it.
+func (sc *Client) SetNoCache(noCache bool) *Client {
+	sc.noCache = noCache
+	return sc
+}
+
+// CacheStats reports the cumulative number of cache hits and misses across
+// every RunQueries call since the cache was installed.
+func (sc *Client) CacheStats() (hits, misses int64) {
+	return atomic.LoadInt64(&sc.cacheHits), atomic.LoadInt64(&sc.cacheMisses)
+}
+
+// lruEntry is one slot of an LRUCache.
+type lruEntry struct {
+	key     string
+	result  *Result
+	expires time.Time
+}
+
+// LRUCache is the default Cache implementation: a fixed-capacity,
+// least-recently-used cache whose entries additionally expire after their
+// TTL. Safe for concurrent use.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// NewLRUCache returns an LRUCache holding at most capacity entries.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(e)
+	return entry.result, true
+}
+
+func (c *LRUCache) Set(key string, r *Result, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*lruEntry)
+		entry.result = r
+		entry.expires = time.Now().Add(ttl)
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	e := c.ll.PushFront(&lruEntry{key: key, result: r, expires: time.Now().Add(ttl)})
+	c.items[key] = e
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package sphinx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCacheGetSet(t *testing.T) {
+	c := NewLRUCache(2)
+
+	r := &Result{Total: 1}
+	c.Set("a", r, time.Minute)
+
+	got, ok := c.Get("a")
+	if !ok || got != r {
+		t.Fatalf("Get(a) > got %v, %v; want %v, true", got, ok, r)
+	}
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatalf("Get(missing) > got ok=true, want false")
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", &Result{Total: 1}, time.Minute)
+	c.Set("b", &Result{Total: 2}, time.Minute)
+
+	// touch "a" so "b" becomes the least-recently-used entry
+	c.Get("a")
+	c.Set("c", &Result{Total: 3}, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("Get(b) > got ok=true, want evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("Get(a) > got ok=false, want still cached")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Fatalf("Get(c) > got ok=false, want cached")
+	}
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", &Result{Total: 1}, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("Get(a) > got ok=true, want expired")
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	req := []byte{1, 2, 3}
+	if fingerprint(req) != fingerprint(append([]byte{}, req...)) {
+		t.Fatalf("fingerprint > not stable across equal-content slices")
+	}
+	if fingerprint(req) == fingerprint([]byte{1, 2, 4}) {
+		t.Fatalf("fingerprint > collided on different content")
+	}
+}
@@ -0,0 +1,349 @@
+package sphinx
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// HashRing is a sorted ring of hashed virtual nodes used to route a
+// sharding key to one of a set of endpoints via consistent hashing — the
+// same technique go-redis's internal/consistenthash package uses to route
+// cluster-mode requests. Safe for concurrent use.
+type HashRing struct {
+	mu       sync.RWMutex
+	replicas int
+	hashFn   func(data []byte) uint32
+	keys     []uint32 // sorted hashes of every virtual node
+	nodes    map[uint32]string
+}
+
+// NewHashRing returns an empty HashRing with replicas virtual nodes per
+// endpoint (100 if replicas <= 0).
+func NewHashRing(replicas int) *HashRing {
+	if replicas <= 0 {
+		replicas = 100
+	}
+	return &HashRing{
+		replicas: replicas,
+		hashFn:   crc32.ChecksumIEEE,
+		nodes:    make(map[uint32]string),
+	}
+}
+
+// Add adds endpoint's virtual nodes to the ring.
+func (h *HashRing) Add(endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := 0; i < h.replicas; i++ {
+		hash := h.hashFn([]byte(strconv.Itoa(i) + endpoint))
+		h.keys = append(h.keys, hash)
+		h.nodes[hash] = endpoint
+	}
+	sort.Slice(h.keys, func(i, j int) bool { return h.keys[i] < h.keys[j] })
+}
+
+// Remove drops endpoint's virtual nodes from the ring.
+func (h *HashRing) Remove(endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.keys[:0]
+	for _, hash := range h.keys {
+		if h.nodes[hash] == endpoint {
+			delete(h.nodes, hash)
+			continue
+		}
+		kept = append(kept, hash)
+	}
+	h.keys = kept
+}
+
+// Get returns the endpoint owning key, walking clockwise from key's hash to
+// the nearest virtual node.
+func (h *HashRing) Get(key string) (string, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.keys) == 0 {
+		return "", fmt.Errorf("HashRing.Get > ring is empty")
+	}
+
+	idx := h.search(h.hashFn([]byte(key)))
+	return h.nodes[h.keys[idx]], nil
+}
+
+// Next returns the next distinct endpoint clockwise from key's position on
+// the ring, skipping any endpoint already in tried. ok is false once every
+// endpoint on the ring has been tried; it's used to fail over to the next
+// replica after a shard error.
+func (h *HashRing) Next(key string, tried map[string]bool) (endpoint string, ok bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.keys) == 0 {
+		return "", false
+	}
+
+	start := h.search(h.hashFn([]byte(key)))
+	for i := 0; i < len(h.keys); i++ {
+		idx := (start + i) % len(h.keys)
+		candidate := h.nodes[h.keys[idx]]
+		if !tried[candidate] {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// search returns the index of the first ring key >= hash, wrapping to 0.
+// Callers must hold h.mu.
+func (h *HashRing) search(hash uint32) int {
+	idx := sort.Search(len(h.keys), func(i int) bool { return h.keys[i] >= hash })
+	if idx == len(h.keys) {
+		idx = 0
+	}
+	return idx
+}
+
+// clientPool holds a small set of persistent (Open'd) Clients for one
+// endpoint, so ClusterClient doesn't pay the connect+handshake cost on
+// every request.
+type clientPool struct {
+	opts *Options
+	size int
+
+	mu   sync.Mutex
+	idle []*Client
+}
+
+// get returns an idle persistent Client, opening a fresh one if the pool is
+// empty.
+func (p *clientPool) get() (*Client, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	c := NewClient(p.opts)
+	if err := c.Open(); err != nil {
+		return nil, fmt.Errorf("clientPool.get > %v", err)
+	}
+	return c, nil
+}
+
+// put returns c to the pool, unless callErr is a connection error or the
+// pool is already at capacity, in which case c is closed instead.
+func (p *clientPool) put(c *Client, callErr error) {
+	if callErr != nil && c.IsConnectError() {
+		c.Close()
+		return
+	}
+
+	p.mu.Lock()
+	if len(p.idle) >= p.size {
+		p.mu.Unlock()
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+func (p *clientPool) closeAll() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.Close()
+	}
+}
+
+// ClusterClient fronts a set of searchd endpoints ("host:port") and routes
+// each call to a shard via consistent hashing on a caller-supplied key (an
+// index name, tenant id, or document id range all work), so a sharded
+// Sphinx deployment can be driven through a single Go API without
+// hand-rolled routing. Each endpoint keeps a small pool of persistent
+// Clients (opened via Client.Open); a shard error walks the ring clockwise
+// to the next replica and retries there.
+type ClusterClient struct {
+	opts     *Options
+	ring     *HashRing
+	poolSize int
+
+	mu    sync.Mutex
+	pools map[string]*clientPool
+}
+
+// NewClusterClient returns a ClusterClient routing across endpoints
+// ("host:port" strings). opts is the template for each endpoint's Clients
+// (Host/Port/Socket are overwritten per endpoint); a nil opts uses
+// DefaultOptions. replicas is the ring's virtual-node count per endpoint
+// (100 if <= 0); poolSize is the max persistent Clients kept idle per
+// endpoint (1 if <= 0).
+func NewClusterClient(endpoints []string, opts *Options, replicas, poolSize int) *ClusterClient {
+	if opts == nil {
+		o := *DefaultOptions
+		opts = &o
+	}
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+
+	ring := NewHashRing(replicas)
+	for _, e := range endpoints {
+		ring.Add(e)
+	}
+
+	return &ClusterClient{
+		opts:     opts,
+		ring:     ring,
+		poolSize: poolSize,
+		pools:    make(map[string]*clientPool),
+	}
+}
+
+// AddEndpoint adds endpoint to the ring, so future shard keys may route to
+// it.
+func (cc *ClusterClient) AddEndpoint(endpoint string) {
+	cc.ring.Add(endpoint)
+}
+
+// RemoveEndpoint drops endpoint from the ring and closes its idle pool.
+func (cc *ClusterClient) RemoveEndpoint(endpoint string) {
+	cc.ring.Remove(endpoint)
+
+	cc.mu.Lock()
+	pool := cc.pools[endpoint]
+	delete(cc.pools, endpoint)
+	cc.mu.Unlock()
+
+	if pool != nil {
+		pool.closeAll()
+	}
+}
+
+func (cc *ClusterClient) poolFor(endpoint string) (*clientPool, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if pool, ok := cc.pools[endpoint]; ok {
+		return pool, nil
+	}
+
+	host, port, err := splitTarget(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	o := *cc.opts
+	o.Host = host
+	o.Port = port
+	o.Socket = ""
+
+	pool := &clientPool{opts: &o, size: cc.poolSize}
+	cc.pools[endpoint] = pool
+	return pool, nil
+}
+
+// do routes key to a shard via the ring and runs fn against a persistent
+// Client bound to it. If fn returns any error (or no Client can be had for
+// the endpoint), it walks the ring clockwise to the next untried replica and
+// retries, until every endpoint has failed.
+func (cc *ClusterClient) do(key string, fn func(c *Client) error) error {
+	endpoint, err := cc.ring.Get(key)
+	if err != nil {
+		return fmt.Errorf("ClusterClient > %v", err)
+	}
+
+	tried := make(map[string]bool)
+	var lastErr error
+	for {
+		tried[endpoint] = true
+
+		pool, poolErr := cc.poolFor(endpoint)
+		if poolErr != nil {
+			return fmt.Errorf("ClusterClient > %v", poolErr)
+		}
+
+		c, getErr := pool.get()
+		if getErr != nil {
+			lastErr = getErr
+		} else {
+			lastErr = fn(c)
+			pool.put(c, lastErr)
+		}
+
+		if lastErr == nil {
+			return nil
+		}
+
+		next, ok := cc.ring.Next(key, tried)
+		if !ok {
+			return fmt.Errorf("ClusterClient > all replicas failed, last error: %v", lastErr)
+		}
+		endpoint = next
+	}
+}
+
+// Query runs a full-text query against key's shard, per Client.Query.
+func (cc *ClusterClient) Query(key, query, index, comment string) (result *Result, err error) {
+	err = cc.do(key, func(c *Client) error {
+		result, err = c.Query(query, index, comment)
+		return err
+	})
+	return
+}
+
+// RunQueries runs a caller-assembled batch of queries against key's shard:
+// build is called with a fresh Client to add queries to via AddQuery, then
+// RunQueries is called on that same Client once build returns.
+func (cc *ClusterClient) RunQueries(key string, build func(c *Client) error) (results []Result, err error) {
+	err = cc.do(key, func(c *Client) error {
+		if buildErr := build(c); buildErr != nil {
+			return buildErr
+		}
+		results, err = c.RunQueries()
+		return err
+	})
+	return
+}
+
+// BuildExcerpts builds excerpts against key's shard, per
+// Client.BuildExcerpts.
+func (cc *ClusterClient) BuildExcerpts(key string, docs []string, index, words string, opts ExcerptsOpts) (resDocs []string, err error) {
+	err = cc.do(key, func(c *Client) error {
+		resDocs, err = c.BuildExcerpts(docs, index, words, opts)
+		return err
+	})
+	return
+}
+
+// UpdateAttributes updates attributes against key's shard, per
+// Client.UpdateAttributes.
+func (cc *ClusterClient) UpdateAttributes(key, index string, attrs []string, values [][]interface{}, ignorenonexistent bool) (ndocs int, err error) {
+	err = cc.do(key, func(c *Client) error {
+		ndocs, err = c.UpdateAttributes(index, attrs, values, ignorenonexistent)
+		return err
+	})
+	return
+}
+
+// Close closes every endpoint's idle pool.
+func (cc *ClusterClient) Close() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	for _, pool := range cc.pools {
+		pool.closeAll()
+	}
+}
@@ -0,0 +1,95 @@
+package sphinx
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHashRingDistributesAcrossEndpoints(t *testing.T) {
+	ring := NewHashRing(100)
+	ring.Add("a:1")
+	ring.Add("b:1")
+	ring.Add("c:1")
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		endpoint, err := ring.Get(strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("Get > %v", err)
+		}
+		seen[endpoint] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("Get > only hit %d of 3 endpoints: %v", len(seen), seen)
+	}
+}
+
+func TestHashRingGetStable(t *testing.T) {
+	ring := NewHashRing(100)
+	ring.Add("a:1")
+	ring.Add("b:1")
+
+	want, err := ring.Get("tenant-42")
+	if err != nil {
+		t.Fatalf("Get > %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		got, err := ring.Get("tenant-42")
+		if err != nil || got != want {
+			t.Fatalf("Get > got %v, %v; want %v, nil", got, err, want)
+		}
+	}
+}
+
+func TestHashRingEmpty(t *testing.T) {
+	ring := NewHashRing(100)
+	if _, err := ring.Get("x"); err == nil {
+		t.Fatalf("Get > got nil error on an empty ring, want one")
+	}
+}
+
+func TestHashRingRemove(t *testing.T) {
+	ring := NewHashRing(100)
+	ring.Add("a:1")
+	ring.Add("b:1")
+	ring.Remove("a:1")
+
+	for i := 0; i < 20; i++ {
+		got, err := ring.Get(strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("Get > %v", err)
+		}
+		if got != "b:1" {
+			t.Fatalf("Get > got %v, want b:1 (a:1 was removed)", got)
+		}
+	}
+}
+
+func TestHashRingNextSkipsTried(t *testing.T) {
+	ring := NewHashRing(100)
+	ring.Add("a:1")
+	ring.Add("b:1")
+	ring.Add("c:1")
+
+	owner, err := ring.Get("key")
+	if err != nil {
+		t.Fatalf("Get > %v", err)
+	}
+
+	tried := map[string]bool{owner: true}
+	next, ok := ring.Next("key", tried)
+	if !ok || next == owner {
+		t.Fatalf("Next > got %v, %v; want a distinct endpoint from %v", next, ok, owner)
+	}
+
+	tried[next] = true
+	next2, ok := ring.Next("key", tried)
+	if !ok || next2 == owner || next2 == next {
+		t.Fatalf("Next > got %v, %v; want the remaining untried endpoint", next2, ok)
+	}
+
+	tried[next2] = true
+	if _, ok := ring.Next("key", tried); ok {
+		t.Fatalf("Next > got ok=true after every endpoint was tried")
+	}
+}
@@ -0,0 +1,318 @@
+package sphinx
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves the live set of searchd targets ("host:port") for a
+// DiscoveryClient and notifies it whenever that set changes, so discovery
+// backends (etcd, Consul, a static list, DNS SRV, ...) can be plugged in
+// without pulling any of them into sphinx's own dependency graph.
+type Resolver interface {
+	// Resolve returns the currently known targets.
+	Resolve() ([]string, error)
+	// Watch calls onChange with the updated target list every time it
+	// changes, until ctx is canceled or watching fails.
+	Watch(ctx context.Context, onChange func(targets []string)) error
+}
+
+// StaticResolver is a Resolver over a fixed target list, for tests and for
+// callers who just want client-side load balancing without a discovery
+// backend.
+type StaticResolver []string
+
+func (r StaticResolver) Resolve() ([]string, error) {
+	return []string(r), nil
+}
+
+func (r StaticResolver) Watch(ctx context.Context, onChange func(targets []string)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Selector picks one target out of the currently healthy set.
+type Selector interface {
+	Select(targets []string) (string, error)
+}
+
+// RoundRobinSelector cycles through targets in order.
+type RoundRobinSelector struct {
+	mu   sync.Mutex
+	next int
+}
+
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{}
+}
+
+func (s *RoundRobinSelector) Select(targets []string) (string, error) {
+	if len(targets) == 0 {
+		return "", fmt.Errorf("RoundRobinSelector.Select > no healthy targets")
+	}
+
+	s.mu.Lock()
+	t := targets[s.next%len(targets)]
+	s.next++
+	s.mu.Unlock()
+	return t, nil
+}
+
+// RandomSelector picks a uniformly random target.
+type RandomSelector struct{}
+
+func NewRandomSelector() *RandomSelector {
+	return &RandomSelector{}
+}
+
+func (s *RandomSelector) Select(targets []string) (string, error) {
+	if len(targets) == 0 {
+		return "", fmt.Errorf("RandomSelector.Select > no healthy targets")
+	}
+	return targets[rand.Intn(len(targets))], nil
+}
+
+// DiscoveryOptions controls a DiscoveryClient's load balancing and failure
+// handling.
+type DiscoveryOptions struct {
+	Selector       Selector      // default: NewRoundRobinSelector()
+	CooldownPeriod time.Duration // how long a target that errored is skipped; 0 disables cooling off
+}
+
+// DiscoveryClient watches a Resolver for the live pool of searchd targets
+// and dispatches each call to one of them, chosen by a Selector. A request
+// that fails with a connection error retries against another target, per
+// the embedded Options' RetryCount/RetryDelay (the same fields
+// Client.SetRetries sets), and marks that target unhealthy for
+// DiscoveryOptions.CooldownPeriod.
+//
+// doRequest lazily dials a Client's conn on first use, so DiscoveryClient
+// gets "per-target connection" for free by building a fresh Client (with Host/Port
+// overridden to the chosen target) for each dispatched request, instead of
+// threading a target through the existing connect()/doRequest plumbing.
+type DiscoveryClient struct {
+	opts  *Options
+	dopts DiscoveryOptions
+
+	cancel context.CancelFunc
+
+	mu        sync.RWMutex
+	targets   []string
+	unhealthy map[string]time.Time // target -> cooldown expiry
+}
+
+// NewDiscoveryClient starts watching resolver in the background and
+// returns a DiscoveryClient that load-balances across the targets it
+// reports. opts is used as the template for each per-target Client (its
+// Host, Port and Socket are overwritten per request); a nil opts uses
+// DefaultOptions. Call Close to stop watching.
+func NewDiscoveryClient(resolver Resolver, opts *Options, dopts *DiscoveryOptions) (*DiscoveryClient, error) {
+	if opts == nil {
+		o := *DefaultOptions
+		opts = &o
+	}
+
+	do := DiscoveryOptions{Selector: NewRoundRobinSelector()}
+	if dopts != nil {
+		do = *dopts
+		if do.Selector == nil {
+			do.Selector = NewRoundRobinSelector()
+		}
+	}
+
+	targets, err := resolver.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("NewDiscoveryClient > %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dc := &DiscoveryClient{
+		opts:    opts,
+		dopts:   do,
+		cancel:  cancel,
+		targets: targets,
+	}
+
+	go resolver.Watch(ctx, dc.setTargets)
+
+	return dc, nil
+}
+
+// Close stops watching the Resolver for target changes.
+func (dc *DiscoveryClient) Close() {
+	dc.cancel()
+}
+
+func (dc *DiscoveryClient) setTargets(targets []string) {
+	dc.mu.Lock()
+	dc.targets = targets
+	dc.mu.Unlock()
+}
+
+func (dc *DiscoveryClient) markUnhealthy(target string) {
+	if dc.dopts.CooldownPeriod <= 0 {
+		return
+	}
+
+	dc.mu.Lock()
+	if dc.unhealthy == nil {
+		dc.unhealthy = make(map[string]time.Time)
+	}
+	dc.unhealthy[target] = time.Now().Add(dc.dopts.CooldownPeriod)
+	dc.mu.Unlock()
+}
+
+// healthyTargets returns the current targets, minus any still cooling off.
+func (dc *DiscoveryClient) healthyTargets() []string {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+
+	if len(dc.unhealthy) == 0 {
+		return dc.targets
+	}
+
+	now := time.Now()
+	healthy := make([]string, 0, len(dc.targets))
+	for _, t := range dc.targets {
+		if until, cooling := dc.unhealthy[t]; cooling && now.Before(until) {
+			continue
+		}
+		healthy = append(healthy, t)
+	}
+	return healthy
+}
+
+// clientFor builds a one-shot Client bound to target.
+func (dc *DiscoveryClient) clientFor(target string) (*Client, error) {
+	host, port, err := splitTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	o := *dc.opts
+	o.Host = host
+	o.Port = port
+	o.Socket = ""
+	return NewClient(&o), nil
+}
+
+// do selects a target and runs fn against a Client bound to it, retrying
+// against a different target (up to Options.RetryCount extra attempts,
+// pausing Options.RetryDelay ms in between) when fn's Client reports a
+// connection error.
+func (dc *DiscoveryClient) do(fn func(c *Client) error) error {
+	attempts := dc.opts.RetryCount + 1
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		target, err := dc.dopts.Selector.Select(dc.healthyTargets())
+		if err != nil {
+			return fmt.Errorf("DiscoveryClient > %v", err)
+		}
+
+		c, err := dc.clientFor(target)
+		if err != nil {
+			return fmt.Errorf("DiscoveryClient > %v", err)
+		}
+
+		lastErr = fn(c)
+		if lastErr == nil {
+			return nil
+		}
+		if !c.IsConnectError() {
+			return lastErr
+		}
+
+		dc.markUnhealthy(target)
+		if i < attempts-1 && dc.opts.RetryDelay > 0 {
+			time.Sleep(time.Duration(dc.opts.RetryDelay) * time.Millisecond)
+		}
+	}
+	return fmt.Errorf("DiscoveryClient > all targets failed, last error: %v", lastErr)
+}
+
+// Query runs a one-shot full-text query against a selected target, per
+// Client.Query.
+func (dc *DiscoveryClient) Query(query, index, comment string) (result *Result, err error) {
+	err = dc.do(func(c *Client) error {
+		result, err = c.Query(query, index, comment)
+		return err
+	})
+	return
+}
+
+// RunQueries runs a caller-assembled batch of queries against a single
+// selected target: build is called with a fresh Client to add queries to
+// via AddQuery, then RunQueries is called on that same Client once build
+// returns.
+func (dc *DiscoveryClient) RunQueries(build func(c *Client) error) (results []Result, err error) {
+	err = dc.do(func(c *Client) error {
+		if buildErr := build(c); buildErr != nil {
+			return buildErr
+		}
+		results, err = c.RunQueries()
+		return err
+	})
+	return
+}
+
+// BuildExcerpts builds excerpts against a selected target, per
+// Client.BuildExcerpts.
+func (dc *DiscoveryClient) BuildExcerpts(docs []string, index, words string, opts ExcerptsOpts) (resDocs []string, err error) {
+	err = dc.do(func(c *Client) error {
+		resDocs, err = c.BuildExcerpts(docs, index, words, opts)
+		return err
+	})
+	return
+}
+
+// BuildKeywords extracts keywords against a selected target, per
+// Client.BuildKeywords.
+func (dc *DiscoveryClient) BuildKeywords(query, index string, hits bool) (keywords []Keyword, err error) {
+	err = dc.do(func(c *Client) error {
+		keywords, err = c.BuildKeywords(query, index, hits)
+		return err
+	})
+	return
+}
+
+// UpdateAttributes updates attributes against a selected target, per
+// Client.UpdateAttributes.
+func (dc *DiscoveryClient) UpdateAttributes(index string, attrs []string, values [][]interface{}, ignorenonexistent bool) (ndocs int, err error) {
+	err = dc.do(func(c *Client) error {
+		ndocs, err = c.UpdateAttributes(index, attrs, values, ignorenonexistent)
+		return err
+	})
+	return
+}
+
+// Status fetches server status from a selected target, per Client.Status.
+func (dc *DiscoveryClient) Status() (response [][]string, err error) {
+	err = dc.do(func(c *Client) error {
+		response, err = c.Status()
+		return err
+	})
+	return
+}
+
+func splitTarget(target string) (host string, port int, err error) {
+	idx := strings.LastIndex(target, ":")
+	if idx < 0 {
+		return "", 0, fmt.Errorf("splitTarget > invalid target %q, want \"host:port\"", target)
+	}
+
+	host = target[:idx]
+	port, err = strconv.Atoi(target[idx+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("splitTarget > invalid port in target %q: %v", target, err)
+	}
+	return host, port, nil
+}
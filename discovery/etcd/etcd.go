@@ -0,0 +1,95 @@
+// Package etcd provides an etcd-backed sphinx.Resolver, plus a convenience
+// constructor that wires one straight into a sphinx.DiscoveryClient.
+// Keeping this in its own subpackage means only callers that import it
+// pull the etcd client into their build; everyone else's dependency graph
+// is unaffected.
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/yunge/sphinx"
+)
+
+// Resolver is a sphinx.Resolver backed by a watched etcd key prefix.
+// Targets are registered as key/value pairs under
+// "<basePath>/<servicePath>/<id>" -> "host:port"; the key itself doesn't
+// matter, only that each value is a "host:port" target string.
+type Resolver struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+// NewResolver dials endpoints and returns a Resolver watching
+// "<basePath>/<servicePath>" for registered searchd instances.
+func NewResolver(basePath, servicePath string, endpoints []string) (*Resolver, error) {
+	cli, err := clientv3.New(clientv3.Config{Endpoints: endpoints})
+	if err != nil {
+		return nil, fmt.Errorf("NewResolver > %v", err)
+	}
+
+	prefix := strings.TrimRight(basePath, "/") + "/" + strings.TrimLeft(servicePath, "/")
+	return &Resolver{cli: cli, prefix: prefix}, nil
+}
+
+// Resolve implements sphinx.Resolver by listing every key under the
+// watched prefix.
+func (r *Resolver) Resolve() ([]string, error) {
+	resp, err := r.cli.Get(context.Background(), r.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("Resolve > %v", err)
+	}
+
+	targets := make([]string, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		targets[i] = string(kv.Value)
+	}
+	return targets, nil
+}
+
+// Watch implements sphinx.Resolver: it re-lists the prefix on every etcd
+// watch event and reports the full resulting target set, so callers never
+// have to reconstruct it from individual put/delete events.
+func (r *Resolver) Watch(ctx context.Context, onChange func(targets []string)) error {
+	watch := r.cli.Watch(ctx, r.prefix, clientv3.WithPrefix())
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resp, ok := <-watch:
+			if !ok {
+				return fmt.Errorf("Watch > etcd watch channel closed")
+			}
+			if err := resp.Err(); err != nil {
+				return fmt.Errorf("Watch > %v", err)
+			}
+
+			targets, err := r.Resolve()
+			if err != nil {
+				return fmt.Errorf("Watch > %v", err)
+			}
+			onChange(targets)
+		}
+	}
+}
+
+// Close releases the underlying etcd client connection.
+func (r *Resolver) Close() error {
+	return r.cli.Close()
+}
+
+// NewDiscoveryClient combines NewResolver with sphinx.NewDiscoveryClient,
+// for callers who just want "point me at etcd" without assembling the
+// Resolver themselves.
+func NewDiscoveryClient(basePath, servicePath string, endpoints []string, opts *sphinx.Options) (*sphinx.DiscoveryClient, error) {
+	resolver, err := NewResolver(basePath, servicePath, endpoints)
+	if err != nil {
+		return nil, fmt.Errorf("NewDiscoveryClient > %v", err)
+	}
+
+	return sphinx.NewDiscoveryClient(resolver, opts, nil)
+}
@@ -0,0 +1,45 @@
+package sphinx
+
+import "testing"
+
+func TestSplitTarget(t *testing.T) {
+	host, port, err := splitTarget("127.0.0.1:9312")
+	if err != nil {
+		t.Fatalf("TestSplitTarget > %v\n", err)
+	}
+	if host != "127.0.0.1" || port != 9312 {
+		t.Fatalf("TestSplitTarget > got (%s, %d)\n", host, port)
+	}
+
+	if _, _, err := splitTarget("no-port-here"); err == nil {
+		t.Fatalf("TestSplitTarget > expected error for missing port\n")
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	targets := []string{"a:1", "b:1", "c:1"}
+	s := NewRoundRobinSelector()
+
+	for i, want := range targets {
+		got, err := s.Select(targets)
+		if err != nil {
+			t.Fatalf("TestRoundRobinSelector > %v\n", err)
+		}
+		if got != want {
+			t.Fatalf("TestRoundRobinSelector > round %d: got %s, want %s\n", i, got, want)
+		}
+	}
+
+	// wraps around
+	got, err := s.Select(targets)
+	if err != nil {
+		t.Fatalf("TestRoundRobinSelector > %v\n", err)
+	}
+	if got != targets[0] {
+		t.Fatalf("TestRoundRobinSelector > wraparound: got %s, want %s\n", got, targets[0])
+	}
+
+	if _, err := s.Select(nil); err == nil {
+		t.Fatalf("TestRoundRobinSelector > expected error for empty targets\n")
+	}
+}
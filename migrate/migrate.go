@@ -0,0 +1,253 @@
+// Package migrate lets callers declare a Sphinx RT index's schema as Go
+// struct tags and reconcile it against a live searchd, and run an ordered
+// list of versioned migrations on top, recording which ones have already
+// been applied (Sphinx itself keeps no DDL history).
+package migrate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yunge/sphinx"
+)
+
+// SyncOptions controls how SyncRT reconciles a struct's declared schema
+// against the live RT index.
+type SyncOptions struct {
+	DryRun     bool // if true, only print the planned ALTER statements; don't run them.
+	DropUnused bool // if true, also DROP COLUMN for index columns not declared on the struct.
+}
+
+// rtColumn is the desired shape of a single RT index column, as declared by
+// a `sphinx:"name,type=rt_attr_uint,stored"` struct tag.
+type rtColumn struct {
+	Name string
+	Type string // rt_attr_uint, rt_attr_bigint, rt_attr_float, rt_attr_string, rt_attr_multi, rt_attr_multi64, rt_attr_json, field, field_string, ...
+}
+
+// SyncRT introspects obj's `sphinx:"name,type=..."` tags to produce the
+// desired column list for index, reads the current schema via DESCRIBE, and
+// runs (or, with SyncOptions.DryRun, just prints) the ALTER RTINDEX
+// statements needed to reconcile the two.
+func SyncRT(sc *sphinx.Client, index string, obj interface{}, opts SyncOptions) error {
+	want, err := columnsFromStruct(obj)
+	if err != nil {
+		return fmt.Errorf("SyncRT > %v", err)
+	}
+
+	have, err := describe(sc, index)
+	if err != nil {
+		return fmt.Errorf("SyncRT > %v", err)
+	}
+
+	for _, stmt := range diff(index, have, want, opts.DropUnused) {
+		if opts.DryRun {
+			fmt.Println(stmt)
+			continue
+		}
+		if _, err := sc.Execute(stmt); err != nil {
+			return fmt.Errorf("SyncRT > %v", err)
+		}
+	}
+	return nil
+}
+
+func columnsFromStruct(obj interface{}) ([]rtColumn, error) {
+	typ := reflect.TypeOf(obj)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("columnsFromStruct > obj must be a struct: %v", typ)
+	}
+
+	var cols []rtColumn
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("sphinx")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		col := rtColumn{Name: parts[0]}
+		for _, opt := range parts[1:] {
+			if t := strings.TrimPrefix(opt, "type="); t != opt {
+				col.Type = t
+			}
+		}
+		if col.Type == "" {
+			return nil, fmt.Errorf("columnsFromStruct > field %s is missing type= in its sphinx tag", typ.Field(i).Name)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// describe runs DESCRIBE <index> and returns the set of (lowercased) column
+// names the live RT index currently has.
+func describe(sc *sphinx.Client, index string) (map[string]bool, error) {
+	var rows []struct {
+		Field string `sphinx:"Field"`
+		Type  string `sphinx:"Type"`
+	}
+	if err := sc.ScanSelect(&rows, "DESCRIBE "+index); err != nil {
+		return nil, fmt.Errorf("describe > %v", err)
+	}
+
+	have := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		have[strings.ToLower(r.Field)] = true
+	}
+	return have, nil
+}
+
+// diff emits "ALTER RTINDEX index ADD COLUMN ..." for every wanted column
+// missing from have (in declaration order), followed by "... DROP COLUMN
+// ..." for unused ones when dropUnused is set. The index's own "id" column
+// is never dropped.
+func diff(index string, have map[string]bool, want []rtColumn, dropUnused bool) []string {
+	wantSet := make(map[string]bool, len(want))
+	var stmts []string
+	for _, col := range want {
+		wantSet[strings.ToLower(col.Name)] = true
+		if have[strings.ToLower(col.Name)] {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER RTINDEX %s ADD COLUMN %s %s", index, col.Name, col.Type))
+	}
+
+	if dropUnused {
+		unused := make([]string, 0, len(have))
+		for name := range have {
+			if name != "id" && !wantSet[name] {
+				unused = append(unused, name)
+			}
+		}
+		sort.Strings(unused) // deterministic statement order
+		for _, name := range unused {
+			stmts = append(stmts, fmt.Sprintf("ALTER RTINDEX %s DROP COLUMN %s", index, name))
+		}
+	}
+
+	return stmts
+}
+
+// Migration is a single versioned schema change. ID must be unique across
+// the Migrator's list; Up applies the change, Down reverts it.
+type Migration struct {
+	ID   string
+	Up   func(sc *sphinx.Client) error
+	Down func(sc *sphinx.Client) error
+}
+
+// migrationRecord is the row shape stored in a Migrator's meta index.
+type migrationRecord struct {
+	Id          int    `sphinx:"id"`
+	MigrationId string `sphinx:"migration_id"`
+}
+
+// Migrator runs an ordered list of Migrations against a Client, recording
+// applied IDs in a dedicated meta RT index since Sphinx keeps no DDL
+// history of its own. The meta index must have an `id` attribute and a
+// `migration_id` string attribute.
+type Migrator struct {
+	sc         *sphinx.Client
+	metaIndex  string
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator that records applied migration IDs in
+// metaIndex.
+func NewMigrator(sc *sphinx.Client, metaIndex string) *Migrator {
+	return &Migrator{sc: sc, metaIndex: metaIndex}
+}
+
+// Use appends migrations to the end of the Migrator's ordered list.
+func (m *Migrator) Use(migrations ...Migration) *Migrator {
+	m.migrations = append(m.migrations, migrations...)
+	return m
+}
+
+// Applied returns the set of migration IDs already recorded in the meta
+// index.
+func (m *Migrator) Applied() (map[string]bool, error) {
+	var records []migrationRecord
+	if err := m.sc.ScanSelect(&records, fmt.Sprintf("SELECT id, migration_id FROM %s", m.metaIndex)); err != nil {
+		return nil, fmt.Errorf("Applied > %v", err)
+	}
+
+	applied := make(map[string]bool, len(records))
+	for _, r := range records {
+		applied[r.MigrationId] = true
+	}
+	return applied, nil
+}
+
+// Up runs every migration not yet recorded as applied, in registration
+// order, recording each one in the meta index as soon as it succeeds.
+func (m *Migrator) Up() error {
+	applied, err := m.Applied()
+	if err != nil {
+		return fmt.Errorf("Migrator.Up > %v", err)
+	}
+
+	for _, mig := range m.migrations {
+		if applied[mig.ID] {
+			continue
+		}
+		if mig.Up == nil {
+			return fmt.Errorf("Migrator.Up > migration %q has no Up func", mig.ID)
+		}
+		if err := mig.Up(m.sc); err != nil {
+			return fmt.Errorf("Migrator.Up > %q > %v", mig.ID, err)
+		}
+		if err := m.record(mig.ID); err != nil {
+			return fmt.Errorf("Migrator.Up > %q applied but failed to record: %v", mig.ID, err)
+		}
+	}
+	return nil
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down() error {
+	applied, err := m.Applied()
+	if err != nil {
+		return fmt.Errorf("Migrator.Down > %v", err)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if !applied[mig.ID] {
+			continue
+		}
+		if mig.Down == nil {
+			return fmt.Errorf("Migrator.Down > migration %q has no Down func", mig.ID)
+		}
+		if err := mig.Down(m.sc); err != nil {
+			return fmt.Errorf("Migrator.Down > %q > %v", mig.ID, err)
+		}
+		return m.unrecord(mig.ID)
+	}
+	return nil
+}
+
+func (m *Migrator) record(id string) error {
+	_, err := m.sc.Exec(fmt.Sprintf("INSERT INTO %s (id, migration_id) VALUES (?, ?)", m.metaIndex), migrationRowID(id), id)
+	return err
+}
+
+func (m *Migrator) unrecord(id string) error {
+	_, err := m.sc.SetIndex(m.metaIndex).Delete(migrationRowID(id))
+	return err
+}
+
+// migrationRowID derives a stable RT document ID from a migration ID, since
+// RT indexes require an explicit id and have no autoincrement.
+func migrationRowID(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32())
+}
@@ -0,0 +1,52 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+type rtSchema struct {
+	Id      int    `sphinx:"id,type=rt_attr_uint"`
+	Title   string `sphinx:"title,type=field"`
+	GroupId int    `sphinx:"group_id,type=rt_attr_uint,stored"`
+}
+
+func TestColumnsFromStruct(t *testing.T) {
+	cols, err := columnsFromStruct(&rtSchema{})
+	if err != nil {
+		t.Fatalf("TestColumnsFromStruct > %v\n", err)
+	}
+
+	want := []rtColumn{
+		{Name: "id", Type: "rt_attr_uint"},
+		{Name: "title", Type: "field"},
+		{Name: "group_id", Type: "rt_attr_uint"},
+	}
+	if !reflect.DeepEqual(cols, want) {
+		t.Fatalf("TestColumnsFromStruct > got %#v, want %#v\n", cols, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	have := map[string]bool{"id": true, "title": true, "legacy_col": true}
+	want := []rtColumn{
+		{Name: "id", Type: "rt_attr_uint"},
+		{Name: "title", Type: "field"},
+		{Name: "group_id", Type: "rt_attr_uint"},
+	}
+
+	stmts := diff("rt", have, want, false)
+	wantStmts := []string{"ALTER RTINDEX rt ADD COLUMN group_id rt_attr_uint"}
+	if !reflect.DeepEqual(stmts, wantStmts) {
+		t.Fatalf("TestDiff > got %#v, want %#v\n", stmts, wantStmts)
+	}
+
+	stmts = diff("rt", have, want, true)
+	wantStmts = []string{
+		"ALTER RTINDEX rt ADD COLUMN group_id rt_attr_uint",
+		"ALTER RTINDEX rt DROP COLUMN legacy_col",
+	}
+	if !reflect.DeepEqual(stmts, wantStmts) {
+		t.Fatalf("TestDiff (dropUnused) > got %#v, want %#v\n", stmts, wantStmts)
+	}
+}
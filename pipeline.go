@@ -0,0 +1,318 @@
+package sphinx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PipelineResult is what a call fired through PipelinedClient.Go receives
+// once searchd's reply has been read off the wire: Res holds the decoded
+// response body (with any SEARCHD_WARNING prefix already stripped) on
+// success, Err holds a read/write failure or a SEARCHD_ERROR/SEARCHD_RETRY
+// status otherwise.
+type PipelineResult struct {
+	Res []byte
+	Err error
+}
+
+type pipelinedWrite struct {
+	seq    uint64
+	framed []byte
+	ch     chan PipelineResult
+}
+
+// PipelinedClient drives a single persistent connection (one opened via
+// Client.Open, which issues SEARCHD_COMMAND_PERSIST) with one writer
+// goroutine and one reader goroutine, so many callers can fire requests
+// concurrently without blocking behind doRequest's implicit one-call-at-a-
+// time serialization, or opening one TCP connection each. searchd replies on
+// a persistent connection in the order requests were sent, so the reader
+// matches each incoming reply to the oldest still-pending call (FIFO); the
+// sequence number Go assigns is purely client-side bookkeeping for that
+// match, not anything sent over the wire.
+//
+// sc must not be used for any other call (Query, RunQueries, ...) once handed
+// to NewPipelinedClient: those serialize through doRequest on the same conn
+// and would corrupt the pipeline's framing.
+type PipelinedClient struct {
+	sc *Client
+
+	mu    sync.Mutex
+	seq   uint64
+	order []pipelinedWrite // calls written to the wire but not yet replied to, oldest first
+
+	writeCh   chan pipelinedWrite
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPipelinedClient wraps sc, which must already be Open'd, with a
+// writer/reader goroutine pair pipelining concurrent calls over sc's single
+// connection.
+func NewPipelinedClient(sc *Client) (*PipelinedClient, error) {
+	if sc.conn == nil {
+		return nil, errors.New("NewPipelinedClient > Client is not Open")
+	}
+
+	pc := &PipelinedClient{
+		sc:      sc,
+		writeCh: make(chan pipelinedWrite, 64),
+		closeCh: make(chan struct{}),
+	}
+	go pc.writeLoop()
+	go pc.readLoop()
+	return pc, nil
+}
+
+// Go frames a single command/version/req call (as doRequest does) and pushes
+// it onto the pipeline. The returned channel receives exactly one
+// PipelineResult once searchd's reply for this call has been read off the
+// wire. Safe to call concurrently from many goroutines.
+func (pc *PipelinedClient) Go(command, version int, req []byte) <-chan PipelineResult {
+	ch := make(chan PipelineResult, 1)
+
+	var framed []byte
+	framed = writeInt16ToBytes(framed, command)
+	framed = writeInt16ToBytes(framed, version)
+	framed = writeInt32ToBytes(framed, len(req))
+	framed = append(framed, req...)
+
+	pc.mu.Lock()
+	pc.seq++
+	seq := pc.seq
+	pc.mu.Unlock()
+
+	select {
+	case pc.writeCh <- pipelinedWrite{seq: seq, framed: framed, ch: ch}:
+	case <-pc.closeCh:
+		ch <- PipelineResult{Err: errors.New("PipelinedClient.Go > closed")}
+		close(ch)
+	}
+
+	return ch
+}
+
+// writeLoop is the pipeline's single writer: it's also where a call becomes
+// "pending" (appended to pc.order), so pc.order always reflects the actual
+// order frames hit the wire, not the order Go() happened to be called in
+// across racing goroutines.
+func (pc *PipelinedClient) writeLoop() {
+	for {
+		select {
+		case w, ok := <-pc.writeCh:
+			if !ok {
+				return
+			}
+			pc.mu.Lock()
+			pc.order = append(pc.order, w)
+			pc.mu.Unlock()
+
+			if _, err := pc.sc.conn.Write(w.framed); err != nil {
+				pc.fail(fmt.Errorf("PipelinedClient > conn.Write > %v", err))
+				return
+			}
+		case <-pc.closeCh:
+			return
+		}
+	}
+}
+
+func (pc *PipelinedClient) readLoop() {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(pc.sc.conn, header); err != nil {
+			pc.fail(fmt.Errorf("PipelinedClient > read header > %v", err))
+			return
+		}
+
+		status := binary.BigEndian.Uint16(header[0:2])
+		ver := binary.BigEndian.Uint16(header[2:4])
+		size := binary.BigEndian.Uint32(header[4:8])
+
+		maxResponseSize := pc.sc.maxResponseSize
+		if maxResponseSize <= 0 {
+			maxResponseSize = defaultMaxResponseSize
+		}
+		if size > uint32(maxResponseSize) {
+			pc.fail(fmt.Errorf("PipelinedClient > response packet size %d exceeds MaxResponseSize %d", size, maxResponseSize))
+			return
+		}
+
+		body := make([]byte, size)
+		if _, err := io.ReadFull(pc.sc.conn, body); err != nil {
+			pc.fail(fmt.Errorf("PipelinedClient > read body (size=%d) > %v", size, err))
+			return
+		}
+
+		pc.deliver(decodePipelineResponse(status, ver, body))
+	}
+}
+
+// decodePipelineResponse mirrors doRequestOnce's status handling: strip a
+// SEARCHD_WARNING prefix on success, or turn SEARCHD_ERROR/SEARCHD_RETRY/an
+// unknown status into Err.
+func decodePipelineResponse(status, ver uint16, body []byte) PipelineResult {
+	bp := byteParser{stream: body}
+
+	switch status {
+	case SEARCHD_OK:
+		return PipelineResult{Res: body}
+	case SEARCHD_WARNING:
+		_ = bp.String() // warning message; callers of Go don't see it, only Res
+		if bp.Err() != nil {
+			return PipelineResult{Err: fmt.Errorf("PipelinedClient > %v", bp.Err())}
+		}
+		return PipelineResult{Res: body[bp.p:]}
+	case SEARCHD_RETRY:
+		message := bp.String()
+		if bp.Err() != nil {
+			return PipelineResult{Err: fmt.Errorf("PipelinedClient > %v", bp.Err())}
+		}
+		return PipelineResult{Err: fmt.Errorf("PipelinedClient > SEARCHD_RETRY: %s", message)}
+	case SEARCHD_ERROR:
+		message := bp.String()
+		if bp.Err() != nil {
+			return PipelineResult{Err: fmt.Errorf("PipelinedClient > %v", bp.Err())}
+		}
+		return PipelineResult{Err: fmt.Errorf("PipelinedClient > SEARCHD_ERROR: %s", message)}
+	default:
+		return PipelineResult{Err: fmt.Errorf("PipelinedClient > unknown status code (status=%d), ver: %d", status, ver)}
+	}
+}
+
+// deliver hands res to the oldest pending call, per the FIFO ordering
+// searchd guarantees on a persistent connection.
+func (pc *PipelinedClient) deliver(res PipelineResult) {
+	pc.mu.Lock()
+	if len(pc.order) == 0 {
+		pc.mu.Unlock()
+		return
+	}
+	w := pc.order[0]
+	pc.order = pc.order[1:]
+	pc.mu.Unlock()
+
+	w.ch <- res
+	close(w.ch)
+}
+
+// fail delivers err to every still-pending call, e.g. after the conn breaks.
+func (pc *PipelinedClient) fail(err error) {
+	pc.mu.Lock()
+	order := pc.order
+	pc.order = nil
+	pc.mu.Unlock()
+
+	for _, w := range order {
+		w.ch <- PipelineResult{Err: err}
+		close(w.ch)
+	}
+}
+
+// Close closes the underlying connection, failing every call still pending.
+func (pc *PipelinedClient) Close() error {
+	pc.closeOnce.Do(func() { close(pc.closeCh) })
+	return pc.sc.Close()
+}
+
+// PipelineQueriesResult is RunQueriesGo's result: the decoded per-query
+// Results on success, or Err.
+type PipelineQueriesResult struct {
+	Results []Result
+	Err     error
+}
+
+// RunQueriesGo builds a batch of queries on a scratch Client sharing pc's
+// Options (build adds them via Client.AddQuery, same shape as
+// ClusterClient.RunQueries' build callback) and fires the batch over the
+// pipeline as one SEARCHD_COMMAND_SEARCH call. The returned channel receives
+// the decoded results once searchd's reply is read.
+func (pc *PipelinedClient) RunQueriesGo(build func(c *Client) error) <-chan PipelineQueriesResult {
+	out := make(chan PipelineQueriesResult, 1)
+
+	scratch := &Client{Options: pc.sc.Options, maxStringLen: pc.sc.maxStringLen, attrAliases: pc.sc.attrAliases}
+	if err := build(scratch); err != nil {
+		out <- PipelineQueriesResult{Err: err}
+		close(out)
+		return out
+	}
+	if len(scratch.reqs) == 0 {
+		out <- PipelineQueriesResult{Err: errors.New("RunQueriesGo > No queries defined, issue AddQuery() first.")}
+		close(out)
+		return out
+	}
+
+	var body []byte
+	body = writeInt32ToBytes(body, 0) // it's a client
+	body = writeInt32ToBytes(body, len(scratch.reqs))
+	for _, r := range scratch.reqs {
+		body = append(body, r...)
+	}
+	nreqs := len(scratch.reqs)
+
+	go func() {
+		res := <-pc.Go(SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, body)
+		if res.Err != nil {
+			out <- PipelineQueriesResult{Err: res.Err}
+			close(out)
+			return
+		}
+
+		bp := byteParser{stream: res.Res, maxStringLen: pc.sc.maxStringLen}
+		results := make([]Result, nreqs)
+		for i := 0; i < nreqs; i++ {
+			result, err := decodeResult(&bp)
+			if err != nil {
+				out <- PipelineQueriesResult{Err: err}
+				close(out)
+				return
+			}
+			scratch.finalizeResult(&result)
+			results[i] = result
+		}
+
+		out <- PipelineQueriesResult{Results: results}
+		close(out)
+	}()
+
+	return out
+}
+
+// PipelineExcerptsResult is BuildExcerptsGo's result: the built excerpts on
+// success, or Err.
+type PipelineExcerptsResult struct {
+	Docs []string
+	Err  error
+}
+
+// BuildExcerptsGo fires a BuildExcerpts call over the pipeline, per
+// Client.BuildExcerpts. The returned channel receives the built excerpts
+// once searchd's reply is read.
+func (pc *PipelinedClient) BuildExcerptsGo(docs []string, index, words string, opts ExcerptsOpts) <-chan PipelineExcerptsResult {
+	out := make(chan PipelineExcerptsResult, 1)
+
+	req, err := buildExcerptsRequest(docs, index, words, opts)
+	if err != nil {
+		out <- PipelineExcerptsResult{Err: err}
+		close(out)
+		return out
+	}
+
+	go func() {
+		res := <-pc.Go(SEARCHD_COMMAND_EXCERPT, VER_COMMAND_EXCERPT, req)
+		if res.Err != nil {
+			out <- PipelineExcerptsResult{Err: res.Err}
+			close(out)
+			return
+		}
+
+		resDocs, err := decodeExcerptsResponse(res.Res, len(docs), pc.sc.maxStringLen)
+		out <- PipelineExcerptsResult{Docs: resDocs, Err: err}
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,127 @@
+package sphinx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+// fakeSearchd echoes each request's body straight back with SEARCHD_OK,
+// preserving receive order -- enough to exercise PipelinedClient's framing
+// and FIFO matching without a real searchd.
+func fakeSearchd(t *testing.T, conn net.Conn, n int) {
+	t.Helper()
+	header := make([]byte, 8)
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		size := binary.BigEndian.Uint32(header[4:8])
+		body := make([]byte, size)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+
+		reply := make([]byte, 8+len(body))
+		binary.BigEndian.PutUint16(reply[0:2], SEARCHD_OK)
+		binary.BigEndian.PutUint16(reply[2:4], 1)
+		binary.BigEndian.PutUint32(reply[4:8], uint32(len(body)))
+		copy(reply[8:], body)
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestPipelinedClientMatchesRepliesUnderConcurrentCallers(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	const n = 50
+	go fakeSearchd(t, serverConn, n)
+
+	sc := &Client{Options: DefaultOptions, conn: clientConn}
+	pc, err := NewPipelinedClient(sc)
+	if err != nil {
+		t.Fatalf("NewPipelinedClient > %v", err)
+	}
+	defer pc.Close()
+
+	// fakeSearchd echoes each request's single-byte body back unchanged, so
+	// whatever byte a goroutine sends is the byte it must get back -- a
+	// mismatch means a reply got matched to the wrong pending call.
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			res := <-pc.Go(SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, []byte{byte(i)})
+			if res.Err != nil {
+				errs <- res.Err
+				return
+			}
+			if len(res.Res) != 1 || res.Res[0] != byte(i) {
+				errs <- fmt.Errorf("Go(%d) > got %v, want [%d]", i, res.Res, i)
+				return
+			}
+			errs <- nil
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPipelinedClientRoundTripsSequentially(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	const n = 10
+	go fakeSearchd(t, serverConn, n)
+
+	sc := &Client{Options: DefaultOptions, conn: clientConn}
+	pc, err := NewPipelinedClient(sc)
+	if err != nil {
+		t.Fatalf("NewPipelinedClient > %v", err)
+	}
+	defer pc.Close()
+
+	for i := 0; i < n; i++ {
+		res := <-pc.Go(SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, []byte{byte(i)})
+		if res.Err != nil {
+			t.Fatalf("Go(%d) > %v", i, res.Err)
+		}
+		if len(res.Res) != 1 || res.Res[0] != byte(i) {
+			t.Fatalf("Go(%d) > got %v, want [%d]", i, res.Res, i)
+		}
+	}
+}
+
+func TestNewPipelinedClientRequiresOpenClient(t *testing.T) {
+	sc := &Client{Options: DefaultOptions}
+	if _, err := NewPipelinedClient(sc); err == nil {
+		t.Fatalf("NewPipelinedClient > got nil error on an un-Open'd Client, want one")
+	}
+}
+
+func TestPipelinedClientFailsPendingCallsOnConnError(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+
+	sc := &Client{Options: DefaultOptions, conn: clientConn}
+	pc, err := NewPipelinedClient(sc)
+	if err != nil {
+		t.Fatalf("NewPipelinedClient > %v", err)
+	}
+
+	ch := pc.Go(SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, []byte{1})
+	serverConn.Close() // break the conn without replying
+
+	res := <-ch
+	if res.Err == nil {
+		t.Fatalf("Go > got nil error after the conn broke, want one")
+	}
+}
@@ -0,0 +1,83 @@
+package sphinx
+
+import (
+	"sync"
+	"time"
+)
+
+// Stop is returned by BackoffPolicy.NextBackoff to signal that no more
+// retries should be attempted, mirroring cenkalti/backoff.Stop.
+const Stop time.Duration = -1
+
+// BackoffPolicy decides how long doRequest waits between retry attempts,
+// modeled on cenkalti/backoff's BackOff interface. It's consulted only for
+// a SEARCHD_RETRY response or a connerror (the conn itself is unusable);
+// SEARCHD_ERROR never retries. A negative return value (Stop) gives up.
+//
+// Implementations are typically stateful (tracking elapsed time and/or the
+// next interval), so — like cenkalti/backoff — a BackoffPolicy is meant to
+// back one Client, not to be shared across Clients retrying concurrently.
+type BackoffPolicy interface {
+	NextBackoff() time.Duration
+}
+
+// SetBackoffPolicy installs p as the retry policy doRequest consults after
+// a SEARCHD_RETRY response or a connerror. A nil p (the default) disables
+// retrying: the first such failure is returned to the caller as-is.
+func (sc *Client) SetBackoffPolicy(p BackoffPolicy) *Client {
+	sc.backoffPolicy = p
+	return sc
+}
+
+// ExponentialBackoff is the default BackoffPolicy: delays grow by
+// Multiplier each attempt, starting at Initial and capped at Max, and
+// NextBackoff returns Stop once MaxElapsed has passed since its first call.
+// Safe for concurrent use, but — per BackoffPolicy's contract — meant to
+// back a single Client's retry sequence, not to be shared.
+type ExponentialBackoff struct {
+	Initial    time.Duration // delay before the first retry. 0 means 100ms.
+	Max        time.Duration // delay cap. 0 means 10s.
+	Multiplier float64       // growth factor per attempt. 0 means 2.0.
+	MaxElapsed time.Duration // give up once this long has passed since the first NextBackoff call. 0 means no limit.
+
+	mu      sync.Mutex
+	next    time.Duration
+	start   time.Time
+	started bool
+}
+
+func (b *ExponentialBackoff) NextBackoff() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	initial := b.Initial
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	max := b.Max
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	mult := b.Multiplier
+	if mult <= 0 {
+		mult = 2.0
+	}
+
+	if !b.started {
+		b.started = true
+		b.start = time.Now()
+		b.next = initial
+	}
+
+	if b.MaxElapsed > 0 && time.Since(b.start) > b.MaxElapsed {
+		return Stop
+	}
+
+	delay := b.next
+	if delay > max {
+		delay = max
+	}
+	b.next = time.Duration(float64(b.next) * mult)
+
+	return delay
+}
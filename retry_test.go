@@ -0,0 +1,126 @@
+package sphinx
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffGrowsAndCaps(t *testing.T) {
+	b := &ExponentialBackoff{Initial: 10 * time.Millisecond, Max: 30 * time.Millisecond, Multiplier: 2}
+
+	first := b.NextBackoff()
+	second := b.NextBackoff()
+	third := b.NextBackoff()
+
+	if first != 10*time.Millisecond {
+		t.Fatalf("NextBackoff #1 > got %v, want 10ms", first)
+	}
+	if second != 20*time.Millisecond {
+		t.Fatalf("NextBackoff #2 > got %v, want 20ms", second)
+	}
+	if third != 30*time.Millisecond {
+		t.Fatalf("NextBackoff #3 > got %v, want capped at 30ms", third)
+	}
+}
+
+func TestExponentialBackoffMaxElapsedStops(t *testing.T) {
+	b := &ExponentialBackoff{Initial: time.Millisecond, MaxElapsed: 5 * time.Millisecond}
+
+	if d := b.NextBackoff(); d != time.Millisecond {
+		t.Fatalf("NextBackoff #1 > got %v, want 1ms", d)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if d := b.NextBackoff(); d != Stop {
+		t.Fatalf("NextBackoff after MaxElapsed > got %v, want Stop", d)
+	}
+}
+
+// handshake performs connect()'s protocol handshake from the server side:
+// send the protocol version, then read the client's.
+func handshake(t *testing.T, conn net.Conn) {
+	t.Helper()
+	versionBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(versionBytes, 1)
+	if _, err := conn.Write(versionBytes); err != nil {
+		t.Fatalf("handshake > write version > %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 4)); err != nil {
+		t.Fatalf("handshake > read client version > %v", err)
+	}
+}
+
+// TestDoRequestContextReconnectsAfterConnError is a regression test for a
+// retry loop that kept resending over a dead socket: the first attempt's
+// connection is accepted and then dropped mid-request (a connerror), and
+// the second attempt must dial a brand new connection rather than reuse
+// the broken one.
+func TestDoRequestContextReconnectsAfterConnError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen > %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan struct{}, 2)
+	go func() {
+		// First connection: complete the handshake, then drop the conn
+		// without answering the request, simulating a connerror.
+		conn1, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- struct{}{}
+		handshake(t, conn1)
+		io.ReadFull(conn1, make([]byte, 8)) // read the command frame header
+		conn1.Close()
+
+		// Second connection: complete the handshake and answer with
+		// SEARCHD_OK and a single-byte body.
+		conn2, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- struct{}{}
+		handshake(t, conn2)
+		io.ReadFull(conn2, make([]byte, 8))
+		reply := make([]byte, 9)
+		binary.BigEndian.PutUint16(reply[0:2], SEARCHD_OK)
+		binary.BigEndian.PutUint32(reply[4:8], 1)
+		conn2.Write(reply)
+		conn2.Close()
+	}()
+
+	host, portStr, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.SplitHostPort > %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("strconv.Atoi > %v", err)
+	}
+
+	sc := &Client{Options: &Options{Host: host, Port: port, Timeout: 1000}}
+	sc.SetBackoffPolicy(&ExponentialBackoff{Initial: time.Millisecond, Max: 5 * time.Millisecond})
+
+	_, err = sc.doRequestContext(context.Background(), SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, []byte{})
+	if err != nil {
+		t.Fatalf("doRequestContext > %v", err)
+	}
+
+	select {
+	case <-accepted:
+	default:
+		t.Fatalf("expected first connection to be accepted")
+	}
+	select {
+	case <-accepted:
+	default:
+		t.Fatalf("expected a second, reconnected connection to be accepted")
+	}
+}
@@ -2,6 +2,7 @@ package sphinx
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/binary"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"net"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -132,6 +134,8 @@ type Match struct {
 	DocId      uint64        // Matched document ID.
 	Weight     int           // Matched document weight.
 	AttrValues []interface{} // Matched document attribute values.
+
+	result *Result // owning Result, for the Get/Int/Float/String/MVA accessors
 }
 
 type WordInfo struct {
@@ -153,6 +157,9 @@ type Result struct {
 	Warning string
 	Error   error
 	Status  int // Query status (refer to SEARCHD_xxx constants in Client).
+
+	attrIndex map[string]int    // AttrNames -> index into AttrTypes/AttrValues
+	aliases   map[string]string // Client.attrAliases, snapshotted when this Result was decoded
 }
 
 type Options struct {
@@ -190,6 +197,13 @@ type Options struct {
 	Index   string // index name for sphinxql query.
 	Columns []string
 	Where   string
+
+	// for sphinxql connection pooling, see GetDb(). Zero value keeps the
+	// previous hard-coded behavior (MaxIdleConns 10, no other limits).
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
 type Client struct {
@@ -203,6 +217,7 @@ type Client struct {
 	weights []int // per-field weights (default is 1 for all fields)
 	filters []filter
 	reqs    [][]byte // requests array for multi-query
+	reqKeys []string // fingerprint(reqs[i]), parallel to reqs
 
 	indexWeights map[string]int
 	fieldWeights map[string]int
@@ -211,6 +226,23 @@ type Client struct {
 	// For sphinxql
 	DB  *sql.DB       // Capitalize, so that can "defer sc.Db.Close()"
 	val reflect.Value // object parameter's reflect value
+
+	retryPolicy *RetryPolicy
+	onQuery     func(sqlStr string, dur time.Duration, err error)
+
+	cache       Cache
+	cacheTTL    time.Duration
+	noCache     bool
+	cacheHits   int64
+	cacheMisses int64
+
+	attrAliases map[string]string
+
+	backoffPolicy BackoffPolicy
+
+	maxStringLen int // 0 means defaultMaxStringLen; see SetMaxStringLen
+
+	maxResponseSize int // 0 means defaultMaxResponseSize; see SetMaxResponseSize
 }
 
 // You can change it, so that you do not need to call Set***() every time.
@@ -601,6 +633,12 @@ func (sc *Client) SetGroupDistinct(groupDistinct string) *Client {
 /***** Querying *****/
 
 func (sc *Client) Query(query, index, comment string) (result *Result, err error) {
+	return sc.QueryContext(context.Background(), query, index, comment)
+}
+
+// QueryContext is Query's context-aware sibling.
+func (sc *Client) QueryContext(ctx context.Context, query, index, comment string) (result *Result, err error) {
+	sc.reqKeys = nil // reset along with reqs below, so the two stay parallel
 	if index == "" {
 		index = "*"
 	}
@@ -611,7 +649,7 @@ func (sc *Client) Query(query, index, comment string) (result *Result, err error
 		return nil, err
 	}
 
-	results, err := sc.RunQueries()
+	results, err := sc.RunQueriesContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -743,132 +781,181 @@ func (sc *Client) AddQuery(query, index, comment string) (i int, err error) {
 
 	// send query, get response
 	sc.reqs = append(sc.reqs, req)
+	sc.reqKeys = append(sc.reqKeys, fingerprint(req))
 	return len(sc.reqs) - 1, nil
 }
 
 //Returns None on network IO failure; or an array of result set hashes on success.
 func (sc *Client) RunQueries() (results []Result, err error) {
+	return sc.RunQueriesContext(context.Background())
+}
+
+// RunQueriesContext is RunQueries' context-aware sibling. If a Cache was
+// installed via SetCache, each pending request is first looked up by its
+// fingerprint(req); only cache misses are sent to searchd, and their
+// decoded results are spliced back into sc.reqs' original order alongside
+// the cache hits before being stored back into the cache.
+func (sc *Client) RunQueriesContext(ctx context.Context) (results []Result, err error) {
 	if len(sc.reqs) == 0 {
 		return nil, fmt.Errorf("RunQueries > No queries defined, issue AddQuery() first.")
 	}
 
 	nreqs := len(sc.reqs)
-	var allReqs []byte
-
-	allReqs = writeInt32ToBytes(allReqs, 0) // it's a client
-	allReqs = writeInt32ToBytes(allReqs, nreqs)
-	for _, req := range sc.reqs {
-		allReqs = append(allReqs, req...)
+	results = make([]Result, nreqs)
+
+	useCache := sc.cache != nil && !sc.noCache
+	misses := make([]int, 0, nreqs)
+	for i, key := range sc.reqKeys {
+		if useCache {
+			if r, ok := sc.cache.Get(key); ok {
+				results[i] = *r
+				atomic.AddInt64(&sc.cacheHits, 1)
+				continue
+			}
+			atomic.AddInt64(&sc.cacheMisses, 1)
+		}
+		misses = append(misses, i)
 	}
 
-	response, err := sc.doRequest(SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, allReqs)
-	if err != nil {
-		return nil, err
+	if len(misses) > 0 {
+		var allReqs []byte
+		allReqs = writeInt32ToBytes(allReqs, 0) // it's a client
+		allReqs = writeInt32ToBytes(allReqs, len(misses))
+		for _, i := range misses {
+			allReqs = append(allReqs, sc.reqs[i]...)
+		}
+
+		response, err := sc.doRequestContext(ctx, SEARCHD_COMMAND_SEARCH, VER_COMMAND_SEARCH, allReqs)
+		if err != nil {
+			return nil, err
+		}
+
+		var bp = byteParser{stream: response, maxStringLen: sc.maxStringLen}
+		for _, i := range misses {
+			result, err := decodeResult(&bp)
+			if err != nil {
+				return nil, err
+			}
+			sc.finalizeResult(&result)
+			results[i] = result
+
+			if useCache && result.Error == nil {
+				sc.cache.Set(sc.reqKeys[i], &result, sc.cacheTTL)
+			}
+		}
 	}
 
-	var bp = byteParser{stream: response}
+	sc.reqs = nil
+	sc.reqKeys = nil
+	return
+}
 
-	for i := 0; i < nreqs; i++ {
-		var result = Result{Status: -1} // Default value of status is 0, but SEARCHD_OK = 0, so must set it to another num.
+// decodeResult reads a single result set off bp, in the wire format
+// RunQueries expects: status, (schema + matches + stats) unless status is
+// a hard error.
+func decodeResult(bp *byteParser) (result Result, err error) {
+	result = Result{Status: -1} // Default value of status is 0, but SEARCHD_OK = 0, so must set it to another num.
 
-		result.Status = bp.Int32()
-		if result.Status != SEARCHD_OK {
-			message := bp.String()
+	result.Status = bp.Int32()
+	if result.Status != SEARCHD_OK {
+		message := bp.String()
 
-			if result.Status == SEARCHD_WARNING {
-				result.Warning = string(message)
-			} else {
-				result.Error = errors.New(string(message))
-				results = append(results, result)
-				continue
+		if result.Status == SEARCHD_WARNING {
+			result.Warning = string(message)
+		} else {
+			result.Error = errors.New(string(message))
+			if bp.Err() != nil {
+				return Result{Status: -1}, fmt.Errorf("decodeResult > %v", bp.Err())
 			}
+			return result, nil
 		}
+	}
 
-		// read schema
-		nfields := bp.Int32()
-		result.Fields = make([]string, nfields)
-		for fieldNum := 0; fieldNum < nfields; fieldNum++ {
-			result.Fields[fieldNum] = bp.String()
-		}
+	// read schema
+	nfields := bp.Count(4) // each field name is at least a 4-byte length prefix
+	result.Fields = make([]string, nfields)
+	for fieldNum := 0; fieldNum < nfields; fieldNum++ {
+		result.Fields[fieldNum] = bp.String()
+	}
 
-		nattrs := bp.Int32()
-		result.AttrNames = make([]string, nattrs)
-		result.AttrTypes = make([]int, nattrs)
-		for attrNum := 0; attrNum < nattrs; attrNum++ {
-			result.AttrNames[attrNum] = bp.String()
-			result.AttrTypes[attrNum] = bp.Int32()
+	nattrs := bp.Count(8) // each attr is at least a 4-byte name length prefix + a 4-byte type
+	result.AttrNames = make([]string, nattrs)
+	result.AttrTypes = make([]int, nattrs)
+	for attrNum := 0; attrNum < nattrs; attrNum++ {
+		result.AttrNames[attrNum] = bp.String()
+		result.AttrTypes[attrNum] = bp.Int32()
+	}
+
+	// read match count
+	count := bp.Count(4) // each match is at least a 4-byte docid (id64 handled below narrows further, but this bound is enough to reject garbage)
+	id64 := bp.Int32()   // if id64 == 1, then docId is uint64
+	result.Matches = make([]Match, count)
+	for matchesNum := 0; matchesNum < count; matchesNum++ {
+		var match Match
+		if id64 == 1 {
+			match.DocId = bp.Uint64()
+		} else {
+			match.DocId = uint64(bp.Uint32())
 		}
+		match.Weight = bp.Int32()
 
-		// read match count
-		count := bp.Int32()
-		id64 := bp.Int32() // if id64 == 1, then docId is uint64
-		result.Matches = make([]Match, count)
-		for matchesNum := 0; matchesNum < count; matchesNum++ {
-			var match Match
-			if id64 == 1 {
-				match.DocId = bp.Uint64()
-			} else {
-				match.DocId = uint64(bp.Uint32())
-			}
-			match.Weight = bp.Int32()
-
-			match.AttrValues = make([]interface{}, nattrs)
-
-			for attrNum := 0; attrNum < len(result.AttrTypes); attrNum++ {
-				attrType := result.AttrTypes[attrNum]
-				switch attrType {
-				case SPH_ATTR_BIGINT:
-					match.AttrValues[attrNum] = bp.Uint64()
-				case SPH_ATTR_FLOAT:
-					f, err := bp.Float32()
-					if err != nil {
-						return nil, fmt.Errorf("binary.Read error: %v", err)
-					}
-					match.AttrValues[attrNum] = f
-				case SPH_ATTR_STRING:
-					match.AttrValues[attrNum] = bp.String()
-				case SPH_ATTR_MULTI: // SPH_ATTR_MULTI is 2^30+1, not an int value.
-					nvals := bp.Int32()
-					var vals = make([]uint32, nvals)
-					for valNum := 0; valNum < nvals; valNum++ {
-						vals[valNum] = bp.Uint32()
-					}
-					match.AttrValues[attrNum] = vals
-				case SPH_ATTR_MULTI64:
-					nvals := bp.Int32()
-					nvals = nvals / 2
-					var vals = make([]uint64, nvals)
-					for valNum := 0; valNum < nvals; valNum++ {
-						vals[valNum] = uint64(bp.Uint32())
-						bp.Uint32()
-					}
-					match.AttrValues[attrNum] = vals
-				default: // handle everything else as unsigned ints
-					match.AttrValues[attrNum] = bp.Uint32()
+		match.AttrValues = make([]interface{}, nattrs)
+
+		for attrNum := 0; attrNum < len(result.AttrTypes); attrNum++ {
+			attrType := result.AttrTypes[attrNum]
+			switch attrType {
+			case SPH_ATTR_BIGINT:
+				match.AttrValues[attrNum] = bp.Uint64()
+			case SPH_ATTR_FLOAT:
+				f, err := bp.Float32()
+				if err != nil {
+					return result, fmt.Errorf("binary.Read error: %v", err)
+				}
+				match.AttrValues[attrNum] = f
+			case SPH_ATTR_STRING:
+				match.AttrValues[attrNum] = bp.String()
+			case SPH_ATTR_MULTI: // SPH_ATTR_MULTI is 2^30+1, not an int value.
+				nvals := bp.Count(4)
+				var vals = make([]uint32, nvals)
+				for valNum := 0; valNum < nvals; valNum++ {
+					vals[valNum] = bp.Uint32()
 				}
+				match.AttrValues[attrNum] = vals
+			case SPH_ATTR_MULTI64:
+				nvals := bp.Count(4)
+				nvals = nvals / 2
+				var vals = make([]uint64, nvals)
+				for valNum := 0; valNum < nvals; valNum++ {
+					vals[valNum] = uint64(bp.Uint32())
+					bp.Uint32()
+				}
+				match.AttrValues[attrNum] = vals
+			default: // handle everything else as unsigned ints
+				match.AttrValues[attrNum] = bp.Uint32()
 			}
-			result.Matches[matchesNum] = match
 		}
+		result.Matches[matchesNum] = match
+	}
 
-		result.Total = bp.Int32()
-		result.TotalFound = bp.Int32()
+	result.Total = bp.Int32()
+	result.TotalFound = bp.Int32()
 
-		msecs := bp.Uint32()
-		result.Time = float32(msecs) / 1000.0
+	msecs := bp.Uint32()
+	result.Time = float32(msecs) / 1000.0
 
-		nwords := bp.Int32()
+	nwords := bp.Count(4)
 
-		result.Words = make([]WordInfo, nwords)
-		for wordNum := 0; wordNum < nwords; wordNum++ {
-			result.Words[wordNum].Word = bp.String()
-			result.Words[wordNum].Docs = bp.Int32()
-			result.Words[wordNum].Hits = bp.Int32()
-		}
+	result.Words = make([]WordInfo, nwords)
+	for wordNum := 0; wordNum < nwords; wordNum++ {
+		result.Words[wordNum].Word = bp.String()
+		result.Words[wordNum].Docs = bp.Int32()
+		result.Words[wordNum].Hits = bp.Int32()
+	}
 
-		results = append(results, result)
+	if bp.Err() != nil {
+		return Result{Status: -1}, fmt.Errorf("decodeResult > %v", bp.Err())
 	}
-	sc.reqs = nil
-	return
+	return result, nil
 }
 
 func (sc *Client) ResetFilters() {
@@ -915,6 +1002,28 @@ type ExcerptsOpts struct {
 }
 
 func (sc *Client) BuildExcerpts(docs []string, index, words string, opts ExcerptsOpts) (resDocs []string, err error) {
+	return sc.BuildExcerptsContext(context.Background(), docs, index, words, opts)
+}
+
+// BuildExcerptsContext is BuildExcerpts' context-aware sibling.
+func (sc *Client) BuildExcerptsContext(ctx context.Context, docs []string, index, words string, opts ExcerptsOpts) (resDocs []string, err error) {
+	req, err := buildExcerptsRequest(docs, index, words, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := sc.doRequestContext(ctx, SEARCHD_COMMAND_EXCERPT, VER_COMMAND_EXCERPT, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeExcerptsResponse(response, len(docs), sc.maxStringLen)
+}
+
+// buildExcerptsRequest validates docs/index/words/opts and builds the
+// SEARCHD_COMMAND_EXCERPT request body, shared by BuildExcerptsContext and
+// PipelinedClient.BuildExcerptsGo.
+func buildExcerptsRequest(docs []string, index, words string, opts ExcerptsOpts) (req []byte, err error) {
 	if len(docs) == 0 {
 		return nil, errors.New("BuildExcerpts > Have no documents to process!")
 	}
@@ -951,7 +1060,6 @@ func (sc *Client) BuildExcerpts(docs []string, index, words string, opts Excerpt
 		opts.StartPassageId = 1
 	}
 
-	var req []byte
 	req = writeInt32ToBytes(req, 0)
 
 	iFlags := 1 // remove_spaces
@@ -1003,17 +1111,21 @@ func (sc *Client) BuildExcerpts(docs []string, index, words string, opts Excerpt
 		req = writeLenStrToBytes(req, doc)
 	}
 
-	response, err := sc.doRequest(SEARCHD_COMMAND_EXCERPT, VER_COMMAND_EXCERPT, req)
-	if err != nil {
-		return nil, err
-	}
+	return req, nil
+}
 
-	var bp = byteParser{stream: response}
+// decodeExcerptsResponse decodes ndocs length-prefixed strings off response,
+// shared by BuildExcerptsContext and PipelinedClient.BuildExcerptsGo.
+func decodeExcerptsResponse(response []byte, ndocs int, maxStringLen int) (resDocs []string, err error) {
+	var bp = byteParser{stream: response, maxStringLen: maxStringLen}
 
-	resDocs = make([]string, len(docs))
-	for i := 0; i < len(docs); i++ {
+	resDocs = make([]string, ndocs)
+	for i := 0; i < ndocs; i++ {
 		resDocs[i] = bp.String()
 	}
+	if bp.Err() != nil {
+		return nil, fmt.Errorf("BuildExcerpts > %v", bp.Err())
+	}
 
 	return resDocs, nil
 }
@@ -1025,6 +1137,11 @@ func (sc *Client) BuildExcerpts(docs []string, index, words string, opts Excerpt
  'ndocs'	-1 on failure, amount of actually found and updated documents (might be 0) on success
 */
 func (sc *Client) UpdateAttributes(index string, attrs []string, values [][]interface{}, ignorenonexistent bool) (ndocs int, err error) {
+	return sc.UpdateAttributesContext(context.Background(), index, attrs, values, ignorenonexistent)
+}
+
+// UpdateAttributesContext is UpdateAttributes' context-aware sibling.
+func (sc *Client) UpdateAttributesContext(ctx context.Context, index string, attrs []string, values [][]interface{}, ignorenonexistent bool) (ndocs int, err error) {
 	if index == "" {
 		return -1, errors.New("UpdateAttributes > index name is empty!")
 	}
@@ -1101,7 +1218,11 @@ func (sc *Client) UpdateAttributes(index string, attrs []string, values [][]inte
 		return -1, err
 	}
 
-	ndocs = int(binary.BigEndian.Uint32(response[0:4]))
+	bp := byteParser{stream: response}
+	ndocs = bp.Int32()
+	if bp.Err() != nil {
+		return -1, fmt.Errorf("UpdateAttributes > %v", bp.Err())
+	}
 	return
 }
 
@@ -1115,6 +1236,11 @@ type Keyword struct {
 // Connect to searchd server, and generate keyword list for a given query.
 // Returns null on failure, an array of Maps with misc per-keyword info on success.
 func (sc *Client) BuildKeywords(query, index string, hits bool) (keywords []Keyword, err error) {
+	return sc.BuildKeywordsContext(context.Background(), query, index, hits)
+}
+
+// BuildKeywordsContext is BuildKeywords' context-aware sibling.
+func (sc *Client) BuildKeywordsContext(ctx context.Context, query, index string, hits bool) (keywords []Keyword, err error) {
 	var req []byte
 	req = writeLenStrToBytes(req, query)
 	req = writeLenStrToBytes(req, index)
@@ -1124,14 +1250,14 @@ func (sc *Client) BuildKeywords(query, index string, hits bool) (keywords []Keyw
 		req = writeInt32ToBytes(req, 0)
 	}
 
-	response, err := sc.doRequest(SEARCHD_COMMAND_KEYWORDS, VER_COMMAND_KEYWORDS, req)
+	response, err := sc.doRequestContext(ctx, SEARCHD_COMMAND_KEYWORDS, VER_COMMAND_KEYWORDS, req)
 	if err != nil {
 		return nil, err
 	}
 
-	var bp = byteParser{stream: response}
+	var bp = byteParser{stream: response, maxStringLen: sc.maxStringLen}
 
-	nwords := bp.Int32()
+	nwords := bp.Count(4) // each keyword is at least a 4-byte tokenized-string length prefix
 
 	keywords = make([]Keyword, nwords)
 
@@ -1147,6 +1273,9 @@ func (sc *Client) BuildKeywords(query, index string, hits bool) (keywords []Keyw
 		}
 		keywords[i] = k
 	}
+	if bp.Err() != nil {
+		return nil, fmt.Errorf("BuildKeywords > %v", bp.Err())
+	}
 
 	return
 }
@@ -1160,31 +1289,49 @@ func EscapeString(s string) string {
 }
 
 func (sc *Client) Status() (response [][]string, err error) {
+	return sc.StatusContext(context.Background())
+}
+
+// StatusContext is Status' context-aware sibling.
+func (sc *Client) StatusContext(ctx context.Context) (response [][]string, err error) {
 	var req []byte
 	req = writeInt32ToBytes(req, 1)
 
-	res, err := sc.doRequest(SEARCHD_COMMAND_STATUS, VER_COMMAND_STATUS, req)
+	res, err := sc.doRequestContext(ctx, SEARCHD_COMMAND_STATUS, VER_COMMAND_STATUS, req)
 	if err != nil {
 		return nil, err
 	}
 
-	var bp = byteParser{stream: res}
+	var bp = byteParser{stream: res, maxStringLen: sc.maxStringLen}
 
-	rows := bp.Uint32()
-	cols := bp.Uint32()
+	rows := bp.Int32()
+	cols := bp.Int32()
+	// rows*cols drives response's allocation below, so bound the product
+	// directly: every cell is at least a 4-byte length prefix.
+	if bp.err == nil && (rows < 0 || cols < 0 || rows > len(bp.stream)-bp.p || (cols > 0 && rows > (len(bp.stream)-bp.p)/4/cols)) {
+		bp.err = fmt.Errorf("byteParser > implausible Status shape %dx%d (only %d bytes remain)", rows, cols, len(bp.stream)-bp.p)
+	}
 
 	response = make([][]string, rows)
-	for i := 0; i < int(rows); i++ {
+	for i := 0; i < rows; i++ {
 		response[i] = make([]string, cols)
-		for j := 0; j < int(cols); j++ {
+		for j := 0; j < cols; j++ {
 			response[i][j] = bp.String()
 		}
 	}
+	if bp.Err() != nil {
+		return nil, fmt.Errorf("Status > %v", bp.Err())
+	}
 	return response, nil
 }
 
 func (sc *Client) FlushAttributes() (iFlushTag int, err error) {
-	res, err := sc.doRequest(SEARCHD_COMMAND_FLUSHATTRS, VER_COMMAND_FLUSHATTRS, []byte{})
+	return sc.FlushAttrsContext(context.Background())
+}
+
+// FlushAttrsContext is FlushAttributes' context-aware sibling.
+func (sc *Client) FlushAttrsContext(ctx context.Context) (iFlushTag int, err error) {
+	res, err := sc.doRequestContext(ctx, SEARCHD_COMMAND_FLUSHATTRS, VER_COMMAND_FLUSHATTRS, []byte{})
 	if err != nil {
 		return -1, err
 	}
@@ -1198,14 +1345,26 @@ func (sc *Client) FlushAttributes() (iFlushTag int, err error) {
 }
 
 func (sc *Client) connect() (err error) {
+	return sc.connectContext(context.Background())
+}
+
+// connectContext is connect's context-aware sibling: when ctx carries a
+// deadline, it governs the dial and handshake instead of Options.Timeout.
+func (sc *Client) connectContext(ctx context.Context) (err error) {
 	if sc.conn != nil {
 		return
 	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
 	// set connerror to false.
 	sc.connerror = false
 
 	timeout := time.Duration(sc.Timeout) * time.Millisecond
+	if dl, ok := ctx.Deadline(); ok {
+		timeout = time.Until(dl)
+	}
 
 	// Try unix socket first.
 	if sc.Socket != "" {
@@ -1223,7 +1382,11 @@ func (sc *Client) connect() (err error) {
 	}
 
 	// Set deadline
-	if err = sc.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+	deadline := time.Now().Add(timeout)
+	if dl, ok := ctx.Deadline(); ok {
+		deadline = dl
+	}
+	if err = sc.conn.SetDeadline(deadline); err != nil {
 		sc.connerror = true
 		return fmt.Errorf("connect() conn.SetDeadline() > %v", err)
 	}
@@ -1287,15 +1450,87 @@ func (sc *Client) Close() error {
 }
 
 func (sc *Client) doRequest(command int, version int, req []byte) (res []byte, err error) {
-	defer func() {
-		if x := recover(); x != nil {
-			res = nil
-			err = fmt.Errorf("doRequest panic > %#v", x)
+	return sc.doRequestContext(context.Background(), command, version, req)
+}
+
+// doRequestContext is doRequest's context-aware sibling: ctx.Deadline()
+// governs the socket read/write deadline (instead of just Options.Timeout),
+// and if ctx is done while a Read/Write below is blocked, the underlying
+// conn is closed to unblock it and the returned error becomes ctx.Err().
+//
+// If a BackoffPolicy was installed via SetBackoffPolicy, a failed attempt
+// that's safe to resend as-is — a SEARCHD_RETRY response, or a connerror
+// (the conn itself is unusable) — is retried after the policy's
+// NextBackoff(), until it returns a negative duration or ctx is done.
+// SEARCHD_ERROR never retries.
+func (sc *Client) doRequestContext(ctx context.Context, command, version int, req []byte) (res []byte, err error) {
+	var retryable bool
+	for {
+		res, retryable, err = sc.doRequestOnce(ctx, command, version, req)
+		if err == nil || !retryable || sc.backoffPolicy == nil {
+			return res, err
+		}
+
+		delay := sc.backoffPolicy.NextBackoff()
+		if delay < 0 {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// doRequestOnce is a single, non-retrying doRequest attempt. retryable
+// reports whether err is safe to resend as-is: a SEARCHD_RETRY response or
+// a connerror, as opposed to ctx itself being done or a SEARCHD_ERROR.
+func (sc *Client) doRequestOnce(ctx context.Context, command, version int, req []byte) (res []byte, retryable bool, err error) {
+	if err = sc.connectContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, false, err
+		}
+		return nil, sc.connerror, err
+	}
+
+	if dl, ok := ctx.Deadline(); ok {
+		if err = sc.conn.SetDeadline(dl); err != nil {
+			sc.connerror = true
+			sc.conn.Close()
+			sc.conn = nil
+			return nil, true, fmt.Errorf("doRequest > conn.SetDeadline() > %v", err)
+		}
+	}
+
+	// If ctx is canceled while a Read/Write below is blocked, close the
+	// conn so it unblocks instead of hanging past the caller's deadline.
+	conn := sc.conn
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
 		}
 	}()
 
-	if err = sc.connect(); err != nil {
-		return nil, err
+	// ctxErr reports whether err (from a failed Write/Read below) is
+	// actually ctx winding down rather than a genuine connerror, and
+	// returns the error to surface along with it. Either way the conn is
+	// now unusable (already closed by the goroutine above on ctx-done, or
+	// left in an unknown state by the failed Write/Read otherwise), so it's
+	// closed and cleared here rather than left for connectContext's
+	// sc.conn != nil early-return to hand back to the next attempt.
+	ctxErr := func(fallback error) (error, bool) {
+		sc.conn.Close()
+		sc.conn = nil
+		if cErr := ctx.Err(); cErr != nil {
+			return cErr, false
+		}
+		return fallback, true
 	}
 
 	var cmdVerLen []byte
@@ -1306,43 +1541,65 @@ func (sc *Client) doRequest(command int, version int, req []byte) (res []byte, e
 	_, err = sc.conn.Write(req)
 	if err != nil {
 		sc.connerror = true
-		return nil, fmt.Errorf("conn.Write error: %v", err)
+		wrapped, retry := ctxErr(fmt.Errorf("conn.Write error: %v", err))
+		return nil, retry, wrapped
 	}
 
 	header := make([]byte, 8)
 	if i, err := io.ReadFull(sc.conn, header); err != nil {
 		sc.connerror = true
-		return nil, fmt.Errorf("doRequest > just read %d bytes into header!", i)
+		wrapped, retry := ctxErr(fmt.Errorf("doRequest > just read %d bytes into header!", i))
+		return nil, retry, wrapped
 	}
 
 	status := binary.BigEndian.Uint16(header[0:2])
 	ver := binary.BigEndian.Uint16(header[2:4])
 	size := binary.BigEndian.Uint32(header[4:8])
 	if size <= 0 {
-		return nil, fmt.Errorf("doRequest > invalid response packet size (len=%d).", size)
+		return nil, false, fmt.Errorf("doRequest > invalid response packet size (len=%d).", size)
+	}
+	maxResponseSize := sc.maxResponseSize
+	if maxResponseSize <= 0 {
+		maxResponseSize = defaultMaxResponseSize
+	}
+	if size > uint32(maxResponseSize) {
+		return nil, false, fmt.Errorf("doRequest > response packet size %d exceeds MaxResponseSize %d", size, maxResponseSize)
 	}
 
 	res = make([]byte, size)
 	if i, err := io.ReadFull(sc.conn, res); err != nil {
 		sc.connerror = true
-		return nil, fmt.Errorf("doRequest > just read %d bytes into res (size=%d).", i, size)
+		wrapped, retry := ctxErr(fmt.Errorf("doRequest > just read %d bytes into res (size=%d).", i, size))
+		return nil, retry, wrapped
 	}
 
+	bp := byteParser{stream: res, maxStringLen: sc.maxStringLen}
 	switch status {
 	case SEARCHD_OK:
 		// do nothing
 	case SEARCHD_WARNING:
-		wlen := binary.BigEndian.Uint32(res[0:4])
-		sc.warning = string(res[4:4+wlen])
-		res = res[4+wlen:]
-	case SEARCHD_ERROR, SEARCHD_RETRY:
-		wlen := binary.BigEndian.Uint32(res[0:4])
-		return nil, fmt.Errorf("doRequest > SEARCHD_ERROR: " + string(res[4:4+wlen]))
+		sc.warning = bp.String()
+		if bp.Err() != nil {
+			return nil, false, fmt.Errorf("doRequest > %v", bp.Err())
+		}
+		res = res[bp.p:]
+	case SEARCHD_RETRY:
+		message := bp.String()
+		if bp.Err() != nil {
+			return nil, false, fmt.Errorf("doRequest > %v", bp.Err())
+		}
+		return nil, true, fmt.Errorf("doRequest > SEARCHD_RETRY: " + message)
+	case SEARCHD_ERROR:
+		message := bp.String()
+		if bp.Err() != nil {
+			return nil, false, fmt.Errorf("doRequest > %v", bp.Err())
+		}
+		return nil, false, fmt.Errorf("doRequest > SEARCHD_ERROR: " + message)
 	default:
-		return nil, fmt.Errorf("doRequest > unknown status code (status=%d), ver: %d", status, ver)
+		return nil, false, fmt.Errorf("doRequest > unknown status code (status=%d), ver: %d", status, ver)
 	}
 
-	return res, nil
+	return res, false, nil
 }
 
 func writeFloat32ToBytes(bs []byte, f float32) []byte {
@@ -1384,43 +1641,147 @@ func DegreeToRadian(degree float32) float32 {
 }
 
 
+// defaultMaxStringLen bounds byteParser.String()'s length prefix when a
+// Client hasn't set its own via SetMaxStringLen: searchd frames carrying a
+// bogus or adversarial length are rejected as a protocol error instead of
+// driving an oversized allocation.
+const defaultMaxStringLen = 64 * 1024 * 1024 // 64MiB
+
+// SetMaxStringLen overrides the maximum length a single length-prefixed
+// string in a searchd response may declare (64MiB by default). A response
+// claiming a longer string is treated as a protocol error rather than an
+// oversized allocation.
+func (sc *Client) SetMaxStringLen(n int) *Client {
+	sc.maxStringLen = n
+	return sc
+}
+
+// defaultMaxResponseSize bounds the size a searchd response packet may
+// declare in its header when a Client hasn't set its own via
+// SetMaxResponseSize: the header's 32-bit size field is attacker-controlled
+// (up to 4GiB) and would otherwise drive an oversized allocation before a
+// single byte of the body has been validated.
+const defaultMaxResponseSize = 128 * 1024 * 1024 // 128MiB
+
+// SetMaxResponseSize overrides the maximum size a single searchd response
+// packet may declare in its header (128MiB by default). A response claiming
+// a larger size is treated as a protocol error rather than an oversized
+// allocation.
+func (sc *Client) SetMaxResponseSize(n int) *Client {
+	sc.maxResponseSize = n
+	return sc
+}
+
+// byteParser decodes a searchd response buffer. Every accessor checks bounds
+// before slicing; once a read fails, bp.err is set (sticky) and every
+// subsequent accessor is a no-op returning its zero value, so a caller only
+// needs to check bp.err (via Err()) once, after decoding is done, rather than
+// after every field.
 type byteParser struct {
-	stream []byte
-	p int
+	stream       []byte
+	p            int
+	maxStringLen int // 0 means defaultMaxStringLen
+	err          error
+}
+
+// Err returns the first error encountered while decoding, if any.
+func (bp *byteParser) Err() error {
+	return bp.err
+}
+
+// need reports whether n more bytes are available at bp.p, setting bp.err
+// (if not already set) and returning false otherwise.
+func (bp *byteParser) need(n int) bool {
+	if bp.err != nil {
+		return false
+	}
+	if n < 0 || bp.p+n < bp.p || bp.p+n > len(bp.stream) {
+		bp.err = fmt.Errorf("byteParser > truncated response: need %d bytes at offset %d, have %d", n, bp.p, len(bp.stream))
+		return false
+	}
+	return true
 }
 
 func (bp *byteParser) Int32() (i int) {
-	i = int(binary.BigEndian.Uint32(bp.stream[bp.p : bp.p+4]))
+	if !bp.need(4) {
+		return 0
+	}
+	i = int(int32(binary.BigEndian.Uint32(bp.stream[bp.p : bp.p+4])))
 	bp.p += 4
 	return
 }
 
 func (bp *byteParser) Uint32() (i uint32) {
+	if !bp.need(4) {
+		return 0
+	}
 	i = binary.BigEndian.Uint32(bp.stream[bp.p : bp.p+4])
 	bp.p += 4
 	return
 }
 
 func (bp *byteParser) Uint64() (i uint64) {
+	if !bp.need(8) {
+		return 0
+	}
 	i = binary.BigEndian.Uint64(bp.stream[bp.p : bp.p+8])
 	bp.p += 8
 	return
 }
 
 func (bp *byteParser) Float32() (f float32, err error) {
-	buf := bytes.NewBuffer(bp.stream[bp.p : bp.p + 4])
+	if !bp.need(4) {
+		return 0, bp.err
+	}
+	buf := bytes.NewBuffer(bp.stream[bp.p : bp.p+4])
 	bp.p += 4
-	if err := binary.Read(buf, binary.BigEndian, &f); err != nil {
-		return 0, err
+	if rerr := binary.Read(buf, binary.BigEndian, &f); rerr != nil {
+		bp.err = rerr
+		return 0, rerr
 	}
 	return f, nil
 }
 
 func (bp *byteParser) String() (s string) {
-	s = ""
-	if slen := bp.Int32(); slen > 0 {
-		s = string(bp.stream[bp.p : bp.p+slen])
-		bp.p += slen
+	slen := bp.Int32()
+	if bp.err != nil || slen <= 0 {
+		return ""
+	}
+
+	maxLen := bp.maxStringLen
+	if maxLen <= 0 {
+		maxLen = defaultMaxStringLen
+	}
+	if slen > maxLen {
+		bp.err = fmt.Errorf("byteParser > String() length %d exceeds MaxStringLen %d", slen, maxLen)
+		return ""
 	}
+	if !bp.need(slen) {
+		return ""
+	}
+
+	s = string(bp.stream[bp.p : bp.p+slen])
+	bp.p += slen
 	return
 }
+
+// Count reads a length prefix that's about to drive a make()+loop (a match
+// count, an attribute count, an MVA value count, ...), rejecting it if it's
+// negative or implausibly large for what's left of the stream given the
+// smallest possible wire size of one element. This is what keeps a bogus
+// length prefix from a compromised/misbehaving searchd from driving a
+// multi-gigabyte allocation.
+func (bp *byteParser) Count(minElemSize int) (n int) {
+	n = bp.Int32()
+	if bp.err != nil {
+		return 0
+	}
+	if minElemSize < 1 {
+		minElemSize = 1
+	}
+	if n < 0 || n > (len(bp.stream)-bp.p)/minElemSize {
+		bp.err = fmt.Errorf("byteParser > implausible count %d (only %d bytes remain)", n, len(bp.stream)-bp.p)
+		return 0
+	}
+	return n
+}
@@ -2,14 +2,19 @@ package sphinx
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"io"
+	"math/rand"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -58,11 +63,24 @@ func (sc *Client) GetDb() (err error) {
 	if sc.DB, err = sql.Open("mysql", addr+"/"); err != nil {
 		return err
 	}
-	
-	// FIXME
-	// The returned DB is safe for concurrent use by multiple goroutines and maintains its own pool of idle connections. 
-	//sc.DB.SetMaxOpenConns(100)
-	sc.DB.SetMaxIdleConns(10)
+
+	// The returned DB is safe for concurrent use by multiple goroutines and
+	// maintains its own pool of idle connections.
+	maxIdleConns := sc.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = 10
+	}
+	sc.DB.SetMaxIdleConns(maxIdleConns)
+
+	if sc.MaxOpenConns > 0 {
+		sc.DB.SetMaxOpenConns(sc.MaxOpenConns)
+	}
+	if sc.ConnMaxLifetime > 0 {
+		sc.DB.SetConnMaxLifetime(sc.ConnMaxLifetime)
+	}
+	if sc.ConnMaxIdleTime > 0 {
+		sc.DB.SetConnMaxIdleTime(sc.ConnMaxIdleTime)
+	}
 
 	return
 }
@@ -89,90 +107,502 @@ func (sc *Client) Init(obj interface{}) (err error) {
 	return
 }
 
-func (sc *Client) Execute(sqlStr string) (result sql.Result, err error) {
-	// Init sql.DB
+func (sc *Client) Execute(sqlStr string) (sql.Result, error) {
+	return sc.ExecuteContext(context.Background(), sqlStr)
+}
+
+func (sc *Client) ExecuteContext(ctx context.Context, sqlStr string) (sql.Result, error) {
+	return sc.ExecContext(ctx, sqlStr)
+}
+
+func (sc *Client) ExecuteReturnRowsAffected(sqlStr string) (rowsAffected int, err error) {
+	return sc.ExecReturnRowsAffectedContext(context.Background(), sqlStr)
+}
+
+// Exec runs sqlStr with positional "?" placeholders bound to args through a
+// prepared statement, so callers no longer need escapeString/QuoteStr to
+// build the statement by hand. It is equivalent to ExecContext with
+// context.Background().
+func (sc *Client) Exec(sqlStr string, args ...interface{}) (sql.Result, error) {
+	return sc.ExecContext(context.Background(), sqlStr, args...)
+}
+
+// ExecContext is the context-aware, retrying sibling of Exec: ctx is
+// threaded into the underlying database/sql call so callers get
+// cancellation, deadlines and tracing, and — if a RetryPolicy was
+// installed via SetRetryPolicy — a failed idempotent statement (REPLACE,
+// TRUNCATE, FLUSH, OPTIMIZE, ATTACH) is retried with backoff instead of
+// being returned straight away. Every attempt is reported to the hook
+// installed via SetOnQuery, if any.
+func (sc *Client) ExecContext(ctx context.Context, sqlStr string, args ...interface{}) (result sql.Result, err error) {
 	if sc.DB == nil {
 		if err = sc.GetDb(); err != nil {
-			return nil, fmt.Errorf("Execute> %v", err)
+			return nil, fmt.Errorf("ExecContext > %v", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		if len(args) == 0 {
+			result, err = sc.DB.ExecContext(ctx, sqlStr)
+		} else {
+			result, err = sc.execPreparedContext(ctx, sqlStr, args...)
+		}
+		if sc.onQuery != nil {
+			sc.onQuery(sqlStr, time.Since(start), err)
+		}
+
+		if err == nil || !sc.shouldRetry(sqlStr, err, attempt) {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sc.retryPolicy.backoff(attempt)):
 		}
 	}
-	// It is rare to Close a DB, as the DB handle is meant to be
-        // long-lived and shared between many goroutines.
-	//defer sc.DB.Close()
-	return sc.DB.Exec(sqlStr)
 }
 
-func (sc *Client) ExecuteReturnRowsAffected(sqlStr string) (rowsAffected int, err error) {
-	result, err := sc.Execute(sqlStr)
+func (sc *Client) execPreparedContext(ctx context.Context, sqlStr string, args ...interface{}) (sql.Result, error) {
+	stmt, err := sc.DB.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, fmt.Errorf("execPreparedContext > %v", err)
+	}
+	defer stmt.Close()
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (sc *Client) ExecReturnRowsAffected(sqlStr string, args ...interface{}) (rowsAffected int, err error) {
+	return sc.ExecReturnRowsAffectedContext(context.Background(), sqlStr, args...)
+}
+
+func (sc *Client) ExecReturnRowsAffectedContext(ctx context.Context, sqlStr string, args ...interface{}) (rowsAffected int, err error) {
+	result, err := sc.ExecContext(ctx, sqlStr, args...)
 	if err != nil {
 		return -1, err
 	}
 	if result == nil {
-		return -1, fmt.Errorf("ExecuteReturnRowsAffected: Nil result")
+		return -1, fmt.Errorf("ExecReturnRowsAffectedContext: Nil result")
 	}
 
 	rowsAffected64, err := result.RowsAffected()
 	if err != nil {
-		return -1, fmt.Errorf("ExecuteReturnRowsAffected: %v", err)
+		return -1, fmt.Errorf("ExecReturnRowsAffectedContext: %v", err)
 	}
 
 	rowsAffected = int(rowsAffected64)
 	if rowsAffected < 0 {
-		return rowsAffected, fmt.Errorf("ExecuteReturnRowsAffected> Nagtive RowsAffected(): %d", rowsAffected)
+		return rowsAffected, fmt.Errorf("ExecReturnRowsAffectedContext> Nagtive RowsAffected(): %d", rowsAffected)
 	}
 	return
 }
 
+// SetRetryPolicy installs the policy ExecContext (and everything built on
+// it — Execute, Insert, Update, Delete, AttachToRT, ...) uses to retry a
+// failed idempotent statement (REPLACE, TRUNCATE, FLUSH, OPTIMIZE, ATTACH).
+// The zero value disables retries.
+func (sc *Client) SetRetryPolicy(p RetryPolicy) *Client {
+	sc.retryPolicy = &p
+	return sc
+}
+
+// SetOnQuery installs a hook invoked after every SphinxQL statement run
+// through Exec/ExecContext (one call per attempt), so callers can wire
+// metrics such as Prometheus or OpenTelemetry.
+func (sc *Client) SetOnQuery(fn func(sqlStr string, dur time.Duration, err error)) *Client {
+	sc.onQuery = fn
+	return sc
+}
+
+func (sc *Client) shouldRetry(sqlStr string, err error, attempt int) bool {
+	if sc.retryPolicy == nil || !isIdempotentSQL(sqlStr) {
+		return false
+	}
+	if attempt >= sc.retryPolicy.maxAttempts() {
+		return false
+	}
+
+	retryOn := sc.retryPolicy.RetryOn
+	if retryOn == nil {
+		retryOn = DefaultRetryOn
+	}
+	return retryOn(err)
+}
+
+// idempotentSQLPrefixes are the statement kinds safe to blindly replay: they
+// either fully overwrite their target (REPLACE) or have no meaningful
+// "partial application" state to worry about (TRUNCATE/FLUSH/OPTIMIZE/
+// ATTACH).
+var idempotentSQLPrefixes = []string{"REPLACE", "TRUNCATE", "FLUSH", "OPTIMIZE", "ATTACH"}
+
+func isIdempotentSQL(sqlStr string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(sqlStr))
+	for _, prefix := range idempotentSQLPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy governs how ExecContext retries a failed, idempotent
+// SphinxQL statement before giving up.
+type RetryPolicy struct {
+	MaxAttempts int                             // total attempts, including the first; <= 0 means 1 (no retry).
+	Backoff     func(attempt int) time.Duration // attempt starts at 1. nil means DefaultBackoff.
+	RetryOn     func(err error) bool            // nil means DefaultRetryOn.
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p != nil && p.Backoff != nil {
+		return p.Backoff(attempt)
+	}
+	return DefaultBackoff(attempt)
+}
+
+// DefaultRetryOn reports whether err looks like a transient SphinxQL
+// failure worth retrying: "retry", "index is locked", or "connection
+// refused".
+func DefaultRetryOn(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"retry", "index is locked", "connection refused"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultBackoff is an exponential backoff (100ms * 2^(attempt-1)), capped
+// at 5s, plus up to 20% jitter.
+func DefaultBackoff(attempt int) time.Duration {
+	d := 100 * time.Millisecond << uint(attempt-1)
+	if d <= 0 || d > 5*time.Second {
+		d = 5 * time.Second
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+var namedParamRe = regexp.MustCompile(`:[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// NamedExec runs sqlStr using ":col"-style named placeholders instead of
+// positional "?" ones, resolving each one against arg — a struct (fields
+// matched by an optional `sphinx:"col"` tag, falling back to the field
+// name) or a map[string]interface{} (matched by key) — before delegating
+// to Exec.
+func (sc *Client) NamedExec(sqlStr string, arg interface{}) (sql.Result, error) {
+	query, args, err := bindNamed(sqlStr, arg)
+	if err != nil {
+		return nil, fmt.Errorf("NamedExec > %v", err)
+	}
+	return sc.Exec(query, args...)
+}
+
+func bindNamed(sqlStr string, arg interface{}) (query string, args []interface{}, err error) {
+	names := namedParamRe.FindAllString(sqlStr, -1)
+	if len(names) == 0 {
+		return sqlStr, nil, nil
+	}
+
+	m, isMap := arg.(map[string]interface{})
+	var val reflect.Value
+	if !isMap {
+		val = reflect.Indirect(reflect.ValueOf(arg))
+		if val.Kind() != reflect.Struct {
+			return "", nil, fmt.Errorf("bindNamed > arg must be a struct or map[string]interface{}: %#v", arg)
+		}
+	}
+
+	args = make([]interface{}, 0, len(names))
+	for _, name := range names {
+		col := name[1:]
+		if isMap {
+			v, ok := m[col]
+			if !ok {
+				return "", nil, fmt.Errorf("bindNamed > missing named param %q", name)
+			}
+			args = append(args, v)
+			continue
+		}
+
+		fieldIndex := getFieldIndexByTag(val.Type(), col)
+		if fieldIndex[0] < 0 {
+			return "", nil, fmt.Errorf("bindNamed > missing named param %q", name)
+		}
+		args = append(args, val.FieldByIndex(fieldIndex).Interface())
+	}
+
+	query = namedParamRe.ReplaceAllString(sqlStr, "?")
+	return query, args, nil
+}
+
+// ScanSelect runs sqlStr as a SphinxQL SELECT and scans every returned row
+// into dest, which must be a pointer to a slice of structs (columns resolved
+// against an optional `sphinx:"col"` tag, falling back to the field name)
+// or a pointer to a slice of a scalar type for single-column selects, e.g.
+// `SELECT id, WEIGHT() AS w, group_id FROM rt WHERE MATCH('...')`.
+func (sc *Client) ScanSelect(dest interface{}, sqlStr string, args ...interface{}) error {
+	return sc.ScanSelectContext(context.Background(), dest, sqlStr, args...)
+}
+
+// ScanSelectContext is ScanSelect's context-aware sibling.
+func (sc *Client) ScanSelectContext(ctx context.Context, dest interface{}, sqlStr string, args ...interface{}) error {
+	sliceVal := reflect.ValueOf(dest)
+	if sliceVal.Kind() != reflect.Ptr || sliceVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ScanSelect > dest must be a pointer to a slice: %#v", dest)
+	}
+	sliceVal = sliceVal.Elem()
+	elemType := sliceVal.Type().Elem()
+
+	rows, err := sc.queryRowsContext(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("ScanSelect > %v", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("ScanSelect > %v", err)
+	}
+
+	for rows.Next() {
+		elemVal := reflect.New(elemType).Elem()
+		if err = scanRow(rows, cols, elemVal); err != nil {
+			return fmt.Errorf("ScanSelect > %v", err)
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elemVal))
+	}
+
+	return rows.Err()
+}
+
+// Get is like ScanSelect, but scans only the first matched row into dest, which
+// must be a pointer to a struct or to a scalar. It returns sql.ErrNoRows if
+// the query matched no rows.
+func (sc *Client) Get(dest interface{}, sqlStr string, args ...interface{}) error {
+	return sc.GetContext(context.Background(), dest, sqlStr, args...)
+}
+
+// GetContext is Get's context-aware sibling.
+func (sc *Client) GetContext(ctx context.Context, dest interface{}, sqlStr string, args ...interface{}) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr {
+		return fmt.Errorf("Get > dest must be a pointer: %#v", dest)
+	}
+
+	rows, err := sc.queryRowsContext(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("Get > %v", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("Get > %v", err)
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("Get > %v", err)
+		}
+		return sql.ErrNoRows
+	}
+
+	if err = scanRow(rows, cols, destVal.Elem()); err != nil {
+		return fmt.Errorf("Get > %v", err)
+	}
+
+	return nil
+}
+
+func (sc *Client) queryRows(sqlStr string, args ...interface{}) (*sql.Rows, error) {
+	return sc.queryRowsContext(context.Background(), sqlStr, args...)
+}
+
+func (sc *Client) queryRowsContext(ctx context.Context, sqlStr string, args ...interface{}) (*sql.Rows, error) {
+	if sc.DB == nil {
+		if err := sc.GetDb(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 0 {
+		return sc.DB.QueryContext(ctx, sqlStr)
+	}
+
+	stmt, err := sc.DB.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+// scanRow reads the current row of rows into elemVal: a struct, with each
+// column resolved via getFieldIndexByTag, or a scalar for single-column
+// selects.
+func scanRow(rows *sql.Rows, cols []string, elemVal reflect.Value) error {
+	raw := make([]sql.RawBytes, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	if elemVal.Kind() != reflect.Struct {
+		if len(cols) != 1 {
+			return fmt.Errorf("scanRow > scalar dest %s needs exactly one column, got %d", elemVal.Type(), len(cols))
+		}
+		return decodeAttr(raw[0], elemVal)
+	}
+
+	typ := elemVal.Type()
+	for i, col := range cols {
+		fieldIndex := getFieldIndexByTag(typ, col)
+		if fieldIndex[0] < 0 {
+			continue // e.g. WEIGHT() with no matching field
+		}
+		if err := decodeAttr(raw[i], elemVal.FieldByIndex(fieldIndex)); err != nil {
+			return fmt.Errorf("scanRow > column %q > %v", col, err)
+		}
+	}
+	return nil
+}
+
+// decodeAttr parses a SphinxQL column's textual wire value into fieldVal,
+// handling the MVA (comma-joined ints), JSON attr, and Y/N bool
+// conventions on top of the usual scalar types.
+func decodeAttr(raw sql.RawBytes, fieldVal reflect.Value) error {
+	if raw == nil {
+		return nil
+	}
+	s := string(raw)
+
+	switch fieldVal.Kind() {
+	case reflect.Bool:
+		fieldVal.SetBool(s == "Y" || s == "1")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetFloat(f)
+	case reflect.String:
+		fieldVal.SetString(s)
+	case reflect.Slice:
+		switch fieldVal.Type().Elem().Kind() {
+		case reflect.Uint8: // []byte
+			fieldVal.SetBytes(append([]byte{}, raw...))
+		case reflect.Uint32, reflect.Uint64: // MVA
+			return decodeMVA(s, fieldVal)
+		default:
+			return json.Unmarshal(raw, fieldVal.Addr().Interface())
+		}
+	case reflect.Map, reflect.Struct, reflect.Ptr:
+		return json.Unmarshal(raw, fieldVal.Addr().Interface())
+	default:
+		return fmt.Errorf("decodeAttr > unsupported field kind: %s", fieldVal.Kind())
+	}
+	return nil
+}
+
+// decodeMVA splits a comma-joined MVA column ("1,2,3") into a []uint32 or
+// []uint64 field.
+func decodeMVA(s string, fieldVal reflect.Value) error {
+	if s == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	slice := reflect.MakeSlice(fieldVal.Type(), len(parts), len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64)
+		if err != nil {
+			return fmt.Errorf("decodeMVA > %v", err)
+		}
+		slice.Index(i).SetUint(n)
+	}
+	fieldVal.Set(slice)
+	return nil
+}
+
 // Sphinx doesn't support LastInsertId now.
-func (sc *Client) insert(obj interface{}, doReplace bool) (err error) {
+func (sc *Client) insert(ctx context.Context, obj interface{}, doReplace bool) (err error) {
 	if err = sc.Init(obj); err != nil {
 		return fmt.Errorf("Insert > %v", err)
 	}
 
-	var colVals []string
+	var args []interface{}
 	// If not set sc.Columns, then use all fields as columns
 	if len(sc.Columns) == 0 {
 		if sc.val.Kind() == reflect.Struct {
-			var appendField func(*[]string, *[]string, reflect.Value) error
-			appendField = func(strs *[]string, vals *[]string, val reflect.Value) (err error) {
+			var appendField func(*[]string, *[]interface{}, reflect.Value) error
+			appendField = func(cols *[]string, vals *[]interface{}, val reflect.Value) (err error) {
 				for i := 0; i < val.NumField(); i++ {
 					fieldVal := val.Field(i)
 					sf := val.Type().Field(i)
 
 					switch fieldVal.Type().Kind() {
 					case reflect.Struct:
-						if err = appendField(strs, vals, fieldVal); err != nil {
+						if err = appendField(cols, vals, fieldVal); err != nil {
 							return err
 						}
 					case reflect.Slice, reflect.Map:
 						// just pass
 					default:
-						*strs = append(*strs, sf.Name)
-						s, err := GetValQuoteStr(fieldVal)
+						*cols = append(*cols, sf.Name)
+						v, err := GetColArg(fieldVal)
 						if err != nil {
 							return err
 						}
-						*vals = append(*vals, s)
+						*vals = append(*vals, v)
 					}
 				}
 
 				return nil
 			}
 
-			if err = appendField(&sc.Columns, &colVals, sc.val); err != nil {
+			if err = appendField(&sc.Columns, &args, sc.val); err != nil {
 				return
 			}
 		} else {
 			// if not struct，then it must just one ‘id’ field, "ID column must be present in all cases."
 			sc.Columns = []string{DefaultPK}
-			s, err := GetValQuoteStr(sc.val)
+			v, err := GetColArg(sc.val)
 			if err != nil {
 				return fmt.Errorf("Insert > %v", err)
 			}
-			colVals = []string{s}
+			args = []interface{}{v}
 		}
 
-	} else if colVals, err = GetColVals(sc.val, sc.Columns); err != nil {
+	} else if args, err = GetColArgs(sc.val, sc.Columns); err != nil {
 		return
 	}
 
@@ -182,10 +612,11 @@ func (sc *Client) insert(obj interface{}, doReplace bool) (err error) {
 	} else {
 		sqlStr = "INSERT"
 	}
-	sqlStr += fmt.Sprintf(" INTO %s (%s) VALUES (%s)", sc.Index, strings.Join(sc.Columns, ","), strings.Join(colVals, ","))
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(args)), ",")
+	sqlStr += fmt.Sprintf(" INTO %s (%s) VALUES (%s)", sc.Index, strings.Join(sc.Columns, ","), placeholders)
 
 	//fmt.Printf("Insert sql: %s\n", sqlStr)
-	if _, err = sc.Execute(sqlStr); err != nil {
+	if _, err = sc.ExecContext(ctx, sqlStr, args...); err != nil {
 		return fmt.Errorf("Insert > %v", err)
 	}
 
@@ -194,16 +625,29 @@ func (sc *Client) insert(obj interface{}, doReplace bool) (err error) {
 
 func (sc *Client) Insert(obj interface{}) error {
 	// false means NOT do REPLACE
-	return sc.insert(obj, false)
+	return sc.insert(context.Background(), obj, false)
+}
+
+func (sc *Client) InsertContext(ctx context.Context, obj interface{}) error {
+	return sc.insert(ctx, obj, false)
 }
 
 func (sc *Client) Replace(obj interface{}) error {
 	// true means DO REPLACE
-	return sc.insert(obj, true)
+	return sc.insert(context.Background(), obj, true)
+}
+
+func (sc *Client) ReplaceContext(ctx context.Context, obj interface{}) error {
+	return sc.insert(ctx, obj, true)
 }
 
 // Must set columns!
 func (sc *Client) Update(obj interface{}) (rowsAffected int, err error) {
+	return sc.UpdateContext(context.Background(), obj)
+}
+
+// Must set columns!
+func (sc *Client) UpdateContext(ctx context.Context, obj interface{}) (rowsAffected int, err error) {
 	if err = sc.Init(obj); err != nil {
 		return -1, fmt.Errorf("Update > %v", err)
 	}
@@ -212,19 +656,15 @@ func (sc *Client) Update(obj interface{}) (rowsAffected int, err error) {
 		return -1, fmt.Errorf("Update > columns is not set!")
 	}
 
-	colVals, err := GetColVals(sc.val, sc.Columns)
+	args, err := GetColArgs(sc.val, sc.Columns)
 	if err != nil {
 		return -1, fmt.Errorf("Update > %v", err)
 	}
 
-	var updateStr string
+	setClauses := make([]string, len(sc.Columns))
 	for i, col := range sc.Columns {
-		if colVals[i][0] == '\'' {
-			return -1, fmt.Errorf("Update > Do not support update string field: %v", colVals)
-		}
-		updateStr += col + "=" + colVals[i] + ","
+		setClauses[i] = col + "=?"
 	}
-	updateStr = updateStr[:len(updateStr)-1]
 
 	// If not set "where", then set WHERE clause to "id=..."
 	if sc.Where == "" {
@@ -239,10 +679,10 @@ func (sc *Client) Update(obj interface{}) (rowsAffected int, err error) {
 		sc.Where = DefaultPK + "=" + strconv.Itoa(int(idVal.Int()))
 	}
 
-	sqlStr := fmt.Sprintf("UPDATE %s SET %s WHERE %s", sc.Index, updateStr, sc.Where)
+	sqlStr := fmt.Sprintf("UPDATE %s SET %s WHERE %s", sc.Index, strings.Join(setClauses, ","), sc.Where)
 	//fmt.Printf("Update sql: %s\n", sqlStr)
 
-	rowsAffected, err = sc.ExecuteReturnRowsAffected(sqlStr)
+	rowsAffected, err = sc.ExecReturnRowsAffectedContext(ctx, sqlStr, args...)
 	if err != nil {
 		return -1, fmt.Errorf("Update> %v\n", err)
 	}
@@ -252,35 +692,43 @@ func (sc *Client) Update(obj interface{}) (rowsAffected int, err error) {
 
 // Must based on ID now.
 func (sc *Client) Delete(obj interface{}) (rowsAffected int, err error) {
+	return sc.DeleteContext(context.Background(), obj)
+}
+
+// Must based on ID now.
+func (sc *Client) DeleteContext(ctx context.Context, obj interface{}) (rowsAffected int, err error) {
 	if err = sc.Init(nil); err != nil {
 		return -1, fmt.Errorf("Delete> %v", err)
 	}
 
 	sqlStr := "DELETE FROM " + sc.Index + " WHERE id "
+	var args []interface{}
 	switch v := obj.(type) {
 	case int:
 		if v <= 0 {
 			return -1, fmt.Errorf("Delete> Invalid id val: %d", v)
 		}
-		sqlStr += "= " + strconv.Itoa(v)
+		sqlStr += "= ?"
+		args = []interface{}{v}
 	case []int:
 		if len(v) == 0 {
 			return -1, fmt.Errorf("Delete> Empty []int")
 		}
 
-		sqlStr += "IN ("
-		for _, id := range v {
+		placeholders := make([]string, len(v))
+		for i, id := range v {
 			if id <= 0 {
 				return -1, fmt.Errorf("Delete> Invalid id val: %d", id)
 			}
-			sqlStr += strconv.Itoa(id) + ","
+			placeholders[i] = "?"
+			args = append(args, id)
 		}
-		sqlStr = sqlStr[:len(sqlStr)-1] + ")" // Change the last "," to ")"
+		sqlStr += "IN (" + strings.Join(placeholders, ",") + ")"
 	default:
 		return -1, fmt.Errorf("Delete> Invalid type, must be int or []int: %#v", obj)
 	}
 
-	rowsAffected, err = sc.ExecuteReturnRowsAffected(sqlStr)
+	rowsAffected, err = sc.ExecReturnRowsAffectedContext(ctx, sqlStr, args...)
 	if err != nil {
 		return 0, fmt.Errorf("Delete>  %v", err)
 	}
@@ -289,11 +737,15 @@ func (sc *Client) Delete(obj interface{}) (rowsAffected int, err error) {
 
 // ATTACH currently supports empty target RT indexes only.
 func (sc *Client) AttachToRT(diskIndex, rtIndex string) error {
+	return sc.AttachToRTContext(context.Background(), diskIndex, rtIndex)
+}
+
+func (sc *Client) AttachToRTContext(ctx context.Context, diskIndex, rtIndex string) error {
 	if diskIndex == "" || rtIndex == "" {
 		return fmt.Errorf("AttachToRT > Empty index name. disk: '%s'  rt: '%s'", diskIndex, rtIndex)
 	}
 
-	if _, err := sc.Execute("ATTACH INDEX " + diskIndex + " TO RTINDEX " + rtIndex); err != nil {
+	if _, err := sc.ExecuteContext(ctx, "ATTACH INDEX "+diskIndex+" TO RTINDEX "+rtIndex); err != nil {
 		return fmt.Errorf("AttachToRT(%s) > %v", rtIndex, err)
 	}
 	return nil
@@ -301,11 +753,15 @@ func (sc *Client) AttachToRT(diskIndex, rtIndex string) error {
 
 // Forcibly flushes RT index RAM chunk contents to disk.
 func (sc *Client) FlushRT(rtIndex string) error {
+	return sc.FlushRTContext(context.Background(), rtIndex)
+}
+
+func (sc *Client) FlushRTContext(ctx context.Context, rtIndex string) error {
 	if rtIndex == "" {
 		return fmt.Errorf("FlushRT > Empty RT index name!")
 	}
 
-	if _, err := sc.Execute("FLUSH RTINDEX " + rtIndex); err != nil {
+	if _, err := sc.ExecuteContext(ctx, "FLUSH RTINDEX "+rtIndex); err != nil {
 		return fmt.Errorf("FlushRT(%s) > %v", rtIndex, err)
 	}
 	return nil
@@ -313,10 +769,14 @@ func (sc *Client) FlushRT(rtIndex string) error {
 
 // Added in 2.1.1-beta, clears the RT index completely.
 func (sc *Client) TruncateRT(rtIndex string) error {
+	return sc.TruncateRTContext(context.Background(), rtIndex)
+}
+
+func (sc *Client) TruncateRTContext(ctx context.Context, rtIndex string) error {
 	if rtIndex == "" {
 		return errors.New("TruncateRT > Empty RT index name!")
 	}
-	if _, err := sc.Execute("TRUNCATE RTINDEX " + rtIndex); err != nil {
+	if _, err := sc.ExecuteContext(ctx, "TRUNCATE RTINDEX "+rtIndex); err != nil {
 		return fmt.Errorf("TruncateRT(%s) > %v", rtIndex, err)
 	}
 	return nil
@@ -324,10 +784,14 @@ func (sc *Client) TruncateRT(rtIndex string) error {
 
 // Added in 2.1.1-beta, enqueues a RT index for optimization in a background thread.
 func (sc *Client) Optimize(rtIndex string) error {
+	return sc.OptimizeContext(context.Background(), rtIndex)
+}
+
+func (sc *Client) OptimizeContext(ctx context.Context, rtIndex string) error {
 	if rtIndex == "" {
 		return errors.New("Optimize > Empty RT index name!")
 	}
-	if _, err := sc.Execute("OPTIMIZE INDEX " + rtIndex); err != nil {
+	if _, err := sc.ExecuteContext(ctx, "OPTIMIZE INDEX "+rtIndex); err != nil {
 		return fmt.Errorf("Optimize(%s) > %v", rtIndex, err)
 	}
 	return nil
@@ -392,6 +856,89 @@ func GetValQuoteStr(val reflect.Value) (string, error) {
 	return "", nil
 }
 
+// GetColArgs is the parameterized counterpart of GetColVals: it resolves
+// cols to their raw Go values instead of pre-quoted SQL literals, so the
+// result can be passed straight to Exec as bind arguments.
+func GetColArgs(val reflect.Value, cols []string) (args []interface{}, err error) {
+	typ := val.Type()
+	// if not struct, then must just have one column.
+	if val.Kind() != reflect.Struct && len(cols) != 1 {
+		return nil, fmt.Errorf("GetColArgs> If not a struct(%s), must have one column: %v", val.Kind(), cols)
+	}
+
+	args = make([]interface{}, len(cols))
+	for i, col := range cols {
+		var fieldVal reflect.Value
+		if val.Kind() == reflect.Struct {
+			fieldIndex := getFieldIndexByName(typ, col)
+			if fieldIndex[0] < 0 {
+				return nil, fmt.Errorf("GetColArgs> Can't found struct field(column): '%s'\n", col)
+			}
+			fieldVal = val.FieldByIndex(fieldIndex)
+		} else {
+			fieldVal = val
+		}
+
+		if args[i], err = GetColArg(fieldVal); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// GetColArg is the parameterized counterpart of GetValQuoteStr: it returns
+// val as a native Go value (for the driver to bind) instead of a quoted SQL
+// literal string.
+func GetColArg(val reflect.Value) (interface{}, error) {
+	switch val.Kind() {
+	case reflect.Bool:
+		if val.Bool() {
+			return "Y", nil
+		}
+		return "N", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return val.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), nil
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Slice: //[]byte
+		if val.Type().Elem().Name() != "uint8" {
+			return nil, fmt.Errorf("GetColArg> slicetype is not []byte: %v", val.Interface())
+		}
+		return val.Interface().([]byte), nil
+	default:
+		return nil, fmt.Errorf("GetColArg> reflect.Value is not a string/int/uint/float/bool/[]byte!\nval: %v", val)
+	}
+}
+
+// getFieldIndexByTag resolves name against each field's `sphinx:"..."` tag
+// first, falling back to the field name, for use by the named-parameter
+// binder where callers may want the SQL column name to differ from the Go
+// field name.
+func getFieldIndexByTag(typ reflect.Type, name string) (index []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if tag := field.Tag.Get("sphinx"); tag == name {
+			return []int{i}
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if subIndex := getFieldIndexByTag(field.Type, name); subIndex[0] >= 0 {
+				return append([]int{i}, subIndex...)
+			}
+		}
+
+		if field.Name == name {
+			return []int{i}
+		}
+	}
+	return []int{-1}
+}
+
 func getFieldIndexByName(typ reflect.Type, name string) (index []int) {
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
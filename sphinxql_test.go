@@ -193,3 +193,36 @@ mysql> select * from rt;
 +------+----------+----------+-----------+------------+----------+-----------+
 5 rows in set (0.01 sec)
 */
+
+func TestBatchInsert(t *testing.T) {
+	fmt.Println("Running Batch insert test...")
+
+	if err := NewClient().TruncateRT(rtIndex); err != nil {
+		t.Fatalf("TestBatchInsert > %v\n", err)
+	}
+
+	b := NewClient().Begin(rtIndex).Columns("Id", "Title", "Content", "Group_id")
+	for i := 1; i <= amount; i++ {
+		rtd := rtData{i, "batch title", "batch content", i * 100}
+		if err := b.Add(&rtd); err != nil {
+			t.Fatalf("TestBatchInsert > %v\n", err)
+		}
+	}
+
+	n, err := b.Commit()
+	if err != nil {
+		t.Fatalf("TestBatchInsert > %v\n", err)
+	}
+	if n != amount {
+		t.Fatalf("TestBatchInsert > committed %d rows, want %d\n", n, amount)
+	}
+
+	res, err := NewClient().Query("batch", rtIndex, "test rt batch insert")
+	if err != nil {
+		t.Fatalf("TestBatchInsert > %v\n", err)
+	}
+
+	if len(res.Matches) != amount {
+		t.Fatalf("TestBatchInsert > Matches: %v\n", res.Matches)
+	}
+}